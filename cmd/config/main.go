@@ -2,9 +2,11 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,12 +23,19 @@ func main() {
 	// Set flags
 	setWorkDuration := setCmd.Int("work", 0, "Work duration in minutes")
 	setBreakDuration := setCmd.Int("break", 0, "Break duration in minutes")
+	setLongBreakDuration := setCmd.Int("long-break", 0, "Long break duration in minutes")
+	setLongBreakEvery := setCmd.Int("long-break-every", -1, "Work sessions per long break (0 = never)")
 	setWarningTime := setCmd.Int("warning", 0, "Warning time in minutes before suspend")
 	setExtendDuration := setCmd.Int("extend", 0, "Extension duration in minutes")
+	setMaxPause := setCmd.Int("max-pause", -1, "Total pause allowed per cycle in minutes (0 = unlimited)")
+	setEndAction := setCmd.String("action", "", "What happens when a work session ends")
+	setEndActionArgs := setCmd.String("action-args", "", "Space-separated arguments for the end action")
 	setAlwaysOn := setCmd.Bool("always-on", false, "Enable always-on mode")
 	setScheduleEnabled := setCmd.Bool("schedule-enabled", false, "Enable scheduled start times")
 	setScheduleStart := setCmd.String("schedule-start", "", "Schedule start time (HH:MM)")
 	setScheduleEnd := setCmd.String("schedule-end", "", "Schedule end time (HH:MM)")
+	setTimezone := setCmd.String("timezone", "", "IANA timezone of the schedule, e.g. Europe/Berlin, or \"local\"")
+	setEndOfDay := setCmd.String("end-of-day", "", "What happens to work under way when a schedule window ends")
 	
 	if len(os.Args) < 2 {
 		printUsage()
@@ -39,10 +48,14 @@ func main() {
 		showConfig()
 	case "set":
 		setCmd.Parse(os.Args[2:])
-		setConfig(setWorkDuration, setBreakDuration, setWarningTime, setExtendDuration, 
-			setAlwaysOn, setScheduleEnabled, setScheduleStart, setScheduleEnd)
+		setConfig(setWorkDuration, setBreakDuration, setLongBreakDuration, setLongBreakEvery, setWarningTime, setExtendDuration, setMaxPause,
+			setEndAction, setEndActionArgs, setAlwaysOn, setScheduleEnabled, setScheduleStart, setScheduleEnd, setTimezone, setEndOfDay)
 	case "interactive":
 		interactiveConfig()
+	case "schedule":
+		scheduleCommand(os.Args[2:])
+	case "validate":
+		validateConfig(os.Args[2:])
 	default:
 		printUsage()
 		os.Exit(1)
@@ -56,26 +69,60 @@ func printUsage() {
 	fmt.Println("  pomoduru-config show                          - Show current configuration")
 	fmt.Println("  pomoduru-config set [flags]                   - Set configuration values")
 	fmt.Println("  pomoduru-config interactive                   - Interactive configuration")
+	fmt.Println("  pomoduru-config schedule list                 - Show the weekly schedule")
+	fmt.Println("  pomoduru-config schedule add [flags]          - Add a window or a day off")
+	fmt.Println("  pomoduru-config schedule remove [flags] [n]   - Remove window n, a day off, a cron schedule or a calendar")
+	fmt.Println("  pomoduru-config schedule next [-n count]      - Preview the next cron start times")
+	fmt.Println("  pomoduru-config validate [file]               - Check a config file, the current one by default")
 	fmt.Println()
 	fmt.Println("Set flags:")
 	fmt.Println("  --work int           Work duration in minutes (default 50)")
 	fmt.Println("  --break int          Break duration in minutes (default 10)")
+	fmt.Println("  --long-break int     Long break duration in minutes (default 30)")
+	fmt.Println("  --long-break-every   Work sessions per long break, 0 = never (default 4)")
 	fmt.Println("  --warning int        Warning time in minutes (default 5)")
 	fmt.Println("  --extend int         Extension duration in minutes (default 5)")
+	fmt.Println("  --max-pause int      Total pause allowed per cycle in minutes, 0 = unlimited (default 0)")
+	fmt.Println("  --action string      End-of-work action (default suspend):")
+	fmt.Printf("                       %s\n", strings.Join(config.EndActions, ", "))
+	fmt.Println("  --action-args        Arguments for the end action, e.g. the command to run")
 	fmt.Println("  --always-on          Enable always-on mode")
 	fmt.Println("  --schedule-enabled   Enable scheduled start times")
 	fmt.Println("  --schedule-start     Schedule start time (HH:MM)")
 	fmt.Println("  --schedule-end       Schedule end time (HH:MM)")
+	fmt.Println("  --timezone           IANA timezone of the schedule, or \"local\" (default local)")
+	fmt.Println("  --end-of-day string  When a window ends, stop work once the pomodoro and its break")
+	fmt.Println("                       are over (finish), when the next break would begin (break),")
+	fmt.Println("                       or straight away (now) (default finish)")
+	fmt.Println()
+	fmt.Println("Schedule flags:")
+	fmt.Println("  --days               Days of a window, e.g. mon-fri, sat,sun or weekdays")
+	fmt.Println("  --start, --end       Window start and end times (HH:MM)")
+	fmt.Println("  --except date        A day off such as a holiday (YYYY-MM-DD)")
+	fmt.Println("  --cron expr          Start work at the times of a 5-field cron expression")
+	fmt.Println("  --calendar file      End work sessions before the busy times in an .ics file")
+	fmt.Println()
+	fmt.Println("Weekly windows replace --schedule-start and --schedule-end once any is added.")
+	fmt.Println("Cron schedules replace the windows: work starts only at their times.")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  pomoduru-config set --work 45 --break 15")
+	fmt.Println("  pomoduru-config set --action lock")
+	fmt.Println("  pomoduru-config set --action command --action-args \"swaylock -f\"")
 	fmt.Println("  pomoduru-config set --schedule-enabled --schedule-start 09:00 --schedule-end 18:00")
+	fmt.Println("  pomoduru-config set --end-of-day break")
+	fmt.Println("  pomoduru-config schedule add --days mon-fri --start 09:00 --end 12:30")
+	fmt.Println("  pomoduru-config schedule add --except 2025-12-25")
+	fmt.Println("  pomoduru-config schedule remove 2")
+	fmt.Println("  pomoduru-config schedule add --cron \"0,30 9-17 * * mon-fri\"")
+	fmt.Println("  pomoduru-config schedule next -n 10")
+	fmt.Println("  pomoduru-config schedule add --calendar ~/calendars/work.ics")
 }
 
 func showConfig() {
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfigUnchecked()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		printError("Error loading config", err)
 		os.Exit(1)
 	}
 	
@@ -83,21 +130,97 @@ func showConfig() {
 	fmt.Println("═══════════════════════════")
 	fmt.Printf("Work Duration:    %d minutes\n", int(cfg.WorkDuration.Minutes()))
 	fmt.Printf("Break Duration:   %d minutes\n", int(cfg.BreakDuration.Minutes()))
+	fmt.Printf("Long Break:       %d minutes every %d sessions\n", int(cfg.LongBreakDuration.Minutes()), cfg.LongBreakEvery)
 	fmt.Printf("Warning Time:     %d minutes\n", int(cfg.WarningTime.Minutes()))
 	fmt.Printf("Extend Duration:  %d minutes\n", int(cfg.ExtendDuration.Minutes()))
+	fmt.Printf("Max Pause:        %s\n", formatMaxPause(cfg.MaxPauseDuration))
+	fmt.Printf("End Action:       %s\n", strings.TrimSpace(cfg.EndAction+" "+strings.Join(cfg.EndActionArgs, " ")))
 	fmt.Printf("Always On:        %t\n", cfg.AlwaysOn)
 	fmt.Printf("Schedule Enabled: %t\n", cfg.ScheduleEnabled)
-	fmt.Printf("Schedule Start:   %s\n", cfg.ScheduleStart)
-	fmt.Printf("Schedule End:     %s\n", cfg.ScheduleEnd)
+	if len(cfg.ScheduleCron) > 0 {
+		fmt.Printf("Schedule Cron:    %s\n", strings.Join(cfg.ScheduleCron, "; "))
+	} else if len(cfg.Schedule.Windows) > 0 {
+		fmt.Printf("Schedule:         %d weekly windows (see pomoduru-config schedule list)\n", len(cfg.Schedule.Windows))
+	} else {
+		fmt.Printf("Schedule Start:   %s\n", cfg.ScheduleStart)
+		fmt.Printf("Schedule End:     %s\n", cfg.ScheduleEnd)
+	}
+	fmt.Printf("Timezone:         %s\n", formatTimezone(cfg.Timezone))
+	fmt.Printf("End of Day:       %s\n", formatEndOfDay(cfg.EndOfDay))
+	if len(cfg.Calendars) > 0 {
+		fmt.Printf("Calendars:        %s\n", strings.Join(cfg.Calendars, ", "))
+	}
+	if len(cfg.Schedule.Exceptions) > 0 {
+		fmt.Printf("Days Off:         %s\n", strings.Join(cfg.Schedule.Exceptions, ", "))
+	}
 	fmt.Printf("\nConfig file: %s\n", config.ConfigPath())
+	
+	if err := cfg.Validate(); err != nil {
+		fmt.Println()
+		printError("Warning", err)
+	}
+}
+
+// validateConfig checks the config file given in args, or the current
+// one, and exits non-zero if it has problems
+func validateConfig(args []string) {
+	path := config.ConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	
+	cfg, err := config.ReadConfig(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		printError(path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: OK\n", path)
+}
+
+// printError prints err after what failed, with each invalid setting on a
+// line of its own
+func printError(what string, err error) {
+	var invalid config.ValidationError
+	if errors.As(err, &invalid) {
+		fmt.Printf("%s: invalid settings\n", what)
+		for _, fe := range invalid {
+			fmt.Printf("  %s\n", fe)
+		}
+		return
+	}
+	fmt.Printf("%s: %v\n", what, err)
+}
+
+// lockConfig locks the config for a change, waiting for another
+// pomoduru-config changing it to finish, and returns the function that
+// unlocks it
+func lockConfig() func() {
+	unlock, err := config.LockConfig(false)
+	if errors.Is(err, config.ErrLocked) {
+		fmt.Println("Waiting for another pomoduru-config to finish...")
+		unlock, err = config.LockConfig(true)
+	}
+	if err != nil {
+		printError("Error locking config", err)
+		os.Exit(1)
+	}
+	return unlock
 }
 
-func setConfig(work, break_, warning, extend *int, alwaysOn, scheduleEnabled *bool, 
-	scheduleStart, scheduleEnd *string) {
+func setConfig(work, break_, longBreak, longBreakEvery, warning, extend, maxPause *int,
+	endAction, endActionArgs *string, alwaysOn, scheduleEnabled *bool,
+	scheduleStart, scheduleEnd, timezone, endOfDay *string) {
 	
-	cfg, err := config.LoadConfig()
+	unlock := lockConfig()
+	defer unlock()
+	
+	cfg, err := config.LoadConfigUnchecked()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		printError("Error loading config", err)
 		os.Exit(1)
 	}
 	
@@ -115,6 +238,18 @@ func setConfig(work, break_, warning, extend *int, alwaysOn, scheduleEnabled *bo
 		fmt.Printf("Break duration set to %d minutes\n", *break_)
 	}
 	
+	if *longBreak > 0 {
+		cfg.LongBreakDuration = time.Duration(*longBreak) * time.Minute
+		changed = true
+		fmt.Printf("Long break duration set to %d minutes\n", *longBreak)
+	}
+	
+	if *longBreakEvery >= 0 {
+		cfg.LongBreakEvery = *longBreakEvery
+		changed = true
+		fmt.Printf("Long break every set to %d sessions\n", *longBreakEvery)
+	}
+	
 	if *warning > 0 {
 		cfg.WarningTime = time.Duration(*warning) * time.Minute
 		changed = true
@@ -127,6 +262,31 @@ func setConfig(work, break_, warning, extend *int, alwaysOn, scheduleEnabled *bo
 		fmt.Printf("Extend duration set to %d minutes\n", *extend)
 	}
 	
+	if *maxPause >= 0 {
+		cfg.MaxPauseDuration = time.Duration(*maxPause) * time.Minute
+		changed = true
+		fmt.Printf("Max pause set to %s\n", formatMaxPause(cfg.MaxPauseDuration))
+	}
+	
+	if *endAction != "" || *endActionArgs != "" {
+		action, args := cfg.EndAction, cfg.EndActionArgs
+		if *endAction != "" {
+			action = *endAction
+			// Arguments belong to the action they were given for
+			args = nil
+		}
+		if *endActionArgs != "" {
+			args = strings.Fields(*endActionArgs)
+		}
+		if err := config.CheckEndAction(action, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.EndAction, cfg.EndActionArgs = action, args
+		changed = true
+		fmt.Printf("End action set to %s\n", strings.TrimSpace(action+" "+strings.Join(args, " ")))
+	}
+	
 	if *alwaysOn {
 		cfg.AlwaysOn = true
 		changed = true
@@ -151,9 +311,32 @@ func setConfig(work, break_, warning, extend *int, alwaysOn, scheduleEnabled *bo
 		fmt.Printf("Schedule end set to %s\n", *scheduleEnd)
 	}
 	
+	if *timezone != "" {
+		cfg.Timezone = *timezone
+		if *timezone == "local" {
+			cfg.Timezone = ""
+		}
+		if _, err := cfg.Location(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		changed = true
+		fmt.Printf("Timezone set to %s\n", formatTimezone(cfg.Timezone))
+	}
+	
+	if *endOfDay != "" {
+		if err := config.CheckEndOfDay(*endOfDay); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.EndOfDay = *endOfDay
+		changed = true
+		fmt.Printf("End of day set to %s\n", *endOfDay)
+	}
+	
 	if changed {
 		if err := config.SaveConfig(cfg); err != nil {
-			fmt.Printf("Error saving config: %v\n", err)
+			printError("Error saving config", err)
 			os.Exit(1)
 		}
 		fmt.Println("Configuration saved successfully!")
@@ -163,9 +346,12 @@ func setConfig(work, break_, warning, extend *int, alwaysOn, scheduleEnabled *bo
 }
 
 func interactiveConfig() {
-	cfg, err := config.LoadConfig()
+	unlock := lockConfig()
+	defer unlock()
+	
+	cfg, err := config.LoadConfigUnchecked()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		printError("Error loading config", err)
 		os.Exit(1)
 	}
 	
@@ -196,6 +382,26 @@ func interactiveConfig() {
 		}
 	}
 	
+	// Long break duration
+	fmt.Printf("Long break duration (minutes) [%d]: ", int(cfg.LongBreakDuration.Minutes()))
+	if scanner.Scan() {
+		if val := strings.TrimSpace(scanner.Text()); val != "" {
+			if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+				cfg.LongBreakDuration = time.Duration(minutes) * time.Minute
+			}
+		}
+	}
+	
+	// Long break every
+	fmt.Printf("Work sessions per long break (0 = never) [%d]: ", cfg.LongBreakEvery)
+	if scanner.Scan() {
+		if val := strings.TrimSpace(scanner.Text()); val != "" {
+			if sessions, err := strconv.Atoi(val); err == nil && sessions >= 0 {
+				cfg.LongBreakEvery = sessions
+			}
+		}
+	}
+	
 	// Warning time
 	fmt.Printf("Warning time (minutes) [%d]: ", int(cfg.WarningTime.Minutes()))
 	if scanner.Scan() {
@@ -216,6 +422,41 @@ func interactiveConfig() {
 		}
 	}
 	
+	// Max pause
+	fmt.Printf("Max pause per cycle (minutes, 0 = unlimited) [%d]: ", int(cfg.MaxPauseDuration.Minutes()))
+	if scanner.Scan() {
+		if val := strings.TrimSpace(scanner.Text()); val != "" {
+			if minutes, err := strconv.Atoi(val); err == nil && minutes >= 0 {
+				cfg.MaxPauseDuration = time.Duration(minutes) * time.Minute
+			}
+		}
+	}
+	
+	// End action
+	fmt.Printf("End action (%s) [%s]: ", strings.Join(config.EndActions, "/"), cfg.EndAction)
+	if scanner.Scan() {
+		if val := strings.TrimSpace(scanner.Text()); val != "" && val != cfg.EndAction {
+			if slices.Contains(config.EndActions, val) {
+				cfg.EndAction = val
+				cfg.EndActionArgs = nil
+			} else {
+				fmt.Printf("  Unknown end action %q, keeping %s\n", val, cfg.EndAction)
+			}
+		}
+	}
+	
+	// End action arguments
+	fmt.Printf("End action arguments [%s]: ", strings.Join(cfg.EndActionArgs, " "))
+	if scanner.Scan() {
+		if val := strings.TrimSpace(scanner.Text()); val != "" {
+			cfg.EndActionArgs = strings.Fields(val)
+		}
+	}
+	if err := config.CheckEndAction(cfg.EndAction, cfg.EndActionArgs); err != nil {
+		fmt.Printf("  %v, falling back to %s\n", err, config.ActionSuspend)
+		cfg.EndAction, cfg.EndActionArgs = config.ActionSuspend, nil
+	}
+	
 	// Always on
 	fmt.Printf("Always on mode (y/n) [%t]: ", cfg.AlwaysOn)
 	if scanner.Scan() {
@@ -232,7 +473,9 @@ func interactiveConfig() {
 		}
 	}
 	
-	if cfg.ScheduleEnabled {
+	if cfg.ScheduleEnabled && len(cfg.Schedule.Windows) > 0 {
+		fmt.Println("Weekly schedule in use; edit it with pomoduru-config schedule")
+	} else if cfg.ScheduleEnabled {
 		// Schedule start
 		fmt.Printf("Schedule start time (HH:MM) [%s]: ", cfg.ScheduleStart)
 		if scanner.Scan() {
@@ -250,11 +493,62 @@ func interactiveConfig() {
 		}
 	}
 	
+	if cfg.ScheduleEnabled {
+		// Timezone
+		fmt.Printf("Schedule timezone (IANA name or local) [%s]: ", formatTimezone(cfg.Timezone))
+		if scanner.Scan() {
+			if val := strings.TrimSpace(scanner.Text()); val == "local" {
+				cfg.Timezone = ""
+			} else if val != "" {
+				if _, err := time.LoadLocation(val); err == nil {
+					cfg.Timezone = val
+				} else {
+					fmt.Printf("  Unknown timezone %q, keeping %s\n", val, formatTimezone(cfg.Timezone))
+				}
+			}
+		}
+	}
+	
+	if cfg.ScheduleEnabled && len(cfg.ScheduleCron) == 0 {
+		// End of day
+		fmt.Printf("When a window ends (%s) [%s]: ", strings.Join(config.EndOfDayPolicies, "/"), formatEndOfDay(cfg.EndOfDay))
+		if scanner.Scan() {
+			if val := strings.TrimSpace(scanner.Text()); val != "" {
+				if config.CheckEndOfDay(val) == nil {
+					cfg.EndOfDay = val
+				} else {
+					fmt.Printf("  Unknown end-of-day policy %q, keeping %s\n", val, formatEndOfDay(cfg.EndOfDay))
+				}
+			}
+		}
+	}
+	
 	if err := config.SaveConfig(cfg); err != nil {
-		fmt.Printf("Error saving config: %v\n", err)
+		printError("Error saving config", err)
 		os.Exit(1)
 	}
 	
 	fmt.Println("\nConfiguration saved successfully!")
 	showConfig()
 }
+
+func formatMaxPause(d time.Duration) string {
+	if d <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d minutes", int(d.Minutes()))
+}
+
+func formatEndOfDay(policy string) string {
+	if policy == "" {
+		return config.EndOfDayFinish
+	}
+	return policy
+}
+
+func formatTimezone(zone string) string {
+	if zone == "" {
+		return "local"
+	}
+	return zone
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/cron"
+	"github.com/aniketvish/pomoduru/internal/ical"
+)
+
+// scheduleCommand runs the schedule subcommands
+func scheduleCommand(args []string) {
+	if len(args) == 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		listSchedule()
+	case "add":
+		addSchedule(args[1:])
+	case "remove":
+		removeSchedule(args[1:])
+	case "next":
+		nextSchedule(args[1:])
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func listSchedule() {
+	cfg, err := config.LoadConfigUnchecked()
+	if err != nil {
+		printError("Error loading config", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("📅 Pomoduru Schedule")
+	fmt.Println("═══════════════════════════")
+	if !cfg.ScheduleEnabled {
+		fmt.Println("(disabled; enable with pomoduru-config set --schedule-enabled)")
+	}
+
+	if len(cfg.ScheduleCron) > 0 {
+		fmt.Println("Work starts at (cron, replacing the windows below):")
+		for _, expr := range cfg.ScheduleCron {
+			fmt.Printf("    %s\n", expr)
+		}
+		fmt.Println()
+	}
+
+	if len(cfg.Schedule.Windows) == 0 {
+		fmt.Printf("Every day %s-%s\n", cfg.ScheduleStart, cfg.ScheduleEnd)
+	}
+	for i, w := range cfg.Schedule.Windows {
+		fmt.Printf("%2d. %s\n", i+1, w)
+	}
+
+	if len(cfg.Schedule.Exceptions) > 0 {
+		fmt.Println()
+		fmt.Println("Days off:")
+		for _, date := range cfg.Schedule.Exceptions {
+			fmt.Printf("    %s\n", date)
+		}
+	}
+
+	if len(cfg.Calendars) > 0 {
+		fmt.Println()
+		fmt.Println("Work ends before busy times in:")
+		for _, path := range cfg.Calendars {
+			fmt.Printf("    %s\n", path)
+		}
+	}
+}
+
+func addSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule add", flag.ExitOnError)
+	days := fs.String("days", "", "Days of the window, e.g. mon-fri")
+	start := fs.String("start", "", "Window start time (HH:MM)")
+	end := fs.String("end", "", "Window end time (HH:MM)")
+	except := fs.String("except", "", "A day off (YYYY-MM-DD)")
+	cronExpr := fs.String("cron", "", "Cron expression starting work sessions, e.g. \"0,30 9-17 * * mon-fri\"")
+	calendar := fs.String("calendar", "", "An .ics file whose busy times work sessions end before")
+	fs.Parse(args)
+
+	unlock := lockConfig()
+	defer unlock()
+
+	cfg, err := config.LoadConfigUnchecked()
+	if err != nil {
+		printError("Error loading config", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *calendar != "":
+		path, err := filepath.Abs(*calendar)
+		if err == nil {
+			_, err = ical.Load(path)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !slices.Contains(cfg.Calendars, path) {
+			cfg.Calendars = append(cfg.Calendars, path)
+		}
+		fmt.Printf("Added calendar %s\n", path)
+
+	case *cronExpr != "":
+		sched, err := cron.Parse(*cronExpr)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !slices.Contains(cfg.ScheduleCron, sched.String()) {
+			cfg.ScheduleCron = append(cfg.ScheduleCron, sched.String())
+		}
+		fmt.Printf("Added cron schedule %s\n", sched)
+
+	case *except != "":
+		if _, err := time.Parse(config.DateLayout, *except); err != nil {
+			fmt.Printf("Error: invalid date %q: want YYYY-MM-DD\n", *except)
+			os.Exit(1)
+		}
+		if !slices.Contains(cfg.Schedule.Exceptions, *except) {
+			cfg.Schedule.Exceptions = append(cfg.Schedule.Exceptions, *except)
+			slices.Sort(cfg.Schedule.Exceptions)
+		}
+		fmt.Printf("Added day off %s\n", *except)
+
+	case *days != "" && *start != "" && *end != "":
+		parsed, err := config.ParseDays(*days)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		w := config.Window{Days: parsed, Start: *start, End: *end}
+		if err := w.Check(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Schedule.Windows = append(cfg.Schedule.Windows, w)
+		fmt.Printf("Added window %s\n", w)
+
+	default:
+		fmt.Println("Error: give --days, --start and --end for a window, --except for a day off, --cron or --calendar")
+		os.Exit(1)
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		printError("Error saving config", err)
+		os.Exit(1)
+	}
+	if !cfg.ScheduleEnabled && *calendar == "" {
+		fmt.Println("Note: scheduling is disabled; enable it with pomoduru-config set --schedule-enabled")
+	}
+}
+
+func removeSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule remove", flag.ExitOnError)
+	except := fs.String("except", "", "Day off to remove (YYYY-MM-DD)")
+	cronExpr := fs.String("cron", "", "Cron expression to remove")
+	calendar := fs.String("calendar", "", "Calendar file to remove")
+	fs.Parse(args)
+
+	unlock := lockConfig()
+	defer unlock()
+
+	cfg, err := config.LoadConfigUnchecked()
+	if err != nil {
+		printError("Error loading config", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *calendar != "":
+		path, _ := filepath.Abs(*calendar)
+		i := slices.Index(cfg.Calendars, path)
+		if i < 0 {
+			fmt.Printf("Error: no calendar %s (see pomoduru-config schedule list)\n", path)
+			os.Exit(1)
+		}
+		cfg.Calendars = slices.Delete(cfg.Calendars, i, i+1)
+		fmt.Printf("Removed calendar %s\n", path)
+
+	case *cronExpr != "":
+		// Compare normalized, as stored by add
+		expr := strings.Join(strings.Fields(*cronExpr), " ")
+		i := slices.Index(cfg.ScheduleCron, expr)
+		if i < 0 {
+			fmt.Printf("Error: no cron schedule %q (see pomoduru-config schedule list)\n", expr)
+			os.Exit(1)
+		}
+		cfg.ScheduleCron = slices.Delete(cfg.ScheduleCron, i, i+1)
+		fmt.Printf("Removed cron schedule %s\n", expr)
+
+	case *except != "":
+		i := slices.Index(cfg.Schedule.Exceptions, *except)
+		if i < 0 {
+			fmt.Printf("Error: %s is not a day off\n", *except)
+			os.Exit(1)
+		}
+		cfg.Schedule.Exceptions = slices.Delete(cfg.Schedule.Exceptions, i, i+1)
+		fmt.Printf("Removed day off %s\n", *except)
+
+	case fs.NArg() == 1:
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || n < 1 || n > len(cfg.Schedule.Windows) {
+			fmt.Printf("Error: no window %q (see pomoduru-config schedule list)\n", fs.Arg(0))
+			os.Exit(1)
+		}
+		w := cfg.Schedule.Windows[n-1]
+		cfg.Schedule.Windows = slices.Delete(cfg.Schedule.Windows, n-1, n)
+		fmt.Printf("Removed window %s\n", w)
+
+	default:
+		fmt.Println("Error: give the number of a window, --except for a day off, --cron or --calendar")
+		os.Exit(1)
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		printError("Error saving config", err)
+		os.Exit(1)
+	}
+}
+
+// nextSchedule previews when the cron schedules start work next
+func nextSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule next", flag.ExitOnError)
+	count := fs.Int("n", 5, "Number of start times to show")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		printError("Error loading config", err)
+		os.Exit(1)
+	}
+	if len(cfg.ScheduleCron) == 0 {
+		fmt.Println("No cron schedules; add one with pomoduru-config schedule add --cron")
+		return
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var scheds []*cron.Schedule
+	for _, expr := range cfg.ScheduleCron {
+		sched, err := cron.Parse(expr)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		scheds = append(scheds, sched)
+	}
+
+	// Merge the schedules, each trigger time once
+	t := time.Now().In(loc)
+	for shown := 0; shown < *count; {
+		var next time.Time
+		var fired []string
+		for _, sched := range scheds {
+			n := sched.Next(t)
+			switch {
+			case n.IsZero():
+			case next.IsZero() || n.Before(next):
+				next, fired = n, []string{sched.String()}
+			case n.Equal(next):
+				fired = append(fired, sched.String())
+			}
+		}
+		if next.IsZero() {
+			break
+		}
+		t = next
+		if cfg.Schedule.IsException(next) {
+			continue
+		}
+		fmt.Printf("%s  (%s)\n", next.Format("Mon 2006-01-02 15:04 MST"), strings.Join(fired, "; "))
+		shown++
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/control"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// clientCommands are the subcommands that drive an already running
+// pomoduru over its control socket
+var clientCommands = map[string]func(c *control.Client, args []string, out io.Writer) error{
+	"start":  startCommand,
+	"stop":   simpleCommand((*control.Client).Stop),
+	"extend": simpleCommand((*control.Client).Extend),
+	"pause":  simpleCommand((*control.Client).Pause),
+	"resume": simpleCommand((*control.Client).Resume),
+	"status": statusCommand,
+}
+
+// runClient runs a client subcommand and exits
+func runClient(name string, args []string) {
+	c := control.NewClient(config.SocketPath())
+	if err := clientCommands[name](c, args, os.Stdout); err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			// Already reported by the flag package
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(0)
+			}
+			os.Exit(2)
+		}
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			err = fmt.Errorf("pomoduru is not running (no socket at %s)", config.SocketPath())
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// usageError is a command line the flag package could not parse
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// parseFlags parses a subcommand's arguments. The flag package reports a
+// problem itself, so it comes back as a usageError.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	return nil
+}
+
+func startCommand(c *control.Client, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	work := fs.Int("work", 0, "Length of this work session in minutes (default from config)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	st, err := c.Start(time.Duration(*work) * time.Minute)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, formatStatus(st))
+	return nil
+}
+
+// simpleCommand adapts a client method taking no arguments
+func simpleCommand(call func(*control.Client) (timer.Status, error)) func(*control.Client, []string, io.Writer) error {
+	return func(c *control.Client, args []string, out io.Writer) error {
+		st, err := call(c)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, formatStatus(st))
+		return nil
+	}
+}
+
+// statusView is the status as printed by `pomoduru status`, in units
+// friendly to scripts and templates
+type statusView struct {
+	State            string `json:"state"`
+	Remaining        string `json:"remaining"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+	TotalSeconds     int    `json:"total_seconds"`
+	Paused           bool   `json:"paused"`
+	ExtendUsed       bool   `json:"extend_used"`
+	Cycle            int    `json:"cycle"`
+	Error            string `json:"error,omitempty"`
+}
+
+func newStatusView(st timer.Status) statusView {
+	remaining := max(st.Remaining, 0)
+	return statusView{
+		State:            st.State.String(),
+		Remaining:        formatClock(remaining),
+		RemainingSeconds: int(remaining.Seconds()),
+		TotalSeconds:     int(st.Total.Seconds()),
+		Paused:           st.Paused,
+		ExtendUsed:       st.ExtendUsed,
+		Cycle:            st.Cycle,
+		Error:            st.Error,
+	}
+}
+
+func statusCommand(c *control.Client, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the status as JSON")
+	format := fs.String("format", "", "Print the status with a Go template, e.g. '{{.State}} {{.Remaining}}'")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	st, err := c.Status()
+	if err != nil {
+		return err
+	}
+	view := newStatusView(st)
+
+	switch {
+	case *asJSON:
+		return json.NewEncoder(out).Encode(view)
+	case *format != "":
+		tmpl, err := template.New("status").Parse(*format)
+		if err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+		if err := tmpl.Execute(out, view); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+		return nil
+	}
+
+	fmt.Fprintln(out, formatStatus(st))
+	return nil
+}
+
+// formatStatus renders a one-line, human readable status
+func formatStatus(st timer.Status) string {
+	line := st.State.String()
+	if st.State != timer.StateIdle && st.State != timer.StateSuspended {
+		line += " " + formatClock(max(st.Remaining, 0))
+	}
+	if st.Paused {
+		line += " (paused)"
+	}
+	if st.Error != "" {
+		line += " - " + st.Error
+	}
+	return line
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
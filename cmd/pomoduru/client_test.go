@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/control"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// fakeDaemon answers every request on a control socket with reply, and
+// records the requests
+type fakeDaemon struct {
+	mu       sync.Mutex
+	reply    control.Response
+	requests []control.Request
+}
+
+// newFakeDaemon serves a fakeDaemon answering with reply on a socket in a
+// temporary directory, and returns a client for it
+func newFakeDaemon(t *testing.T, reply control.Response) (*control.Client, *fakeDaemon) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pomoduru.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	d := &fakeDaemon{reply: reply}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.handle(conn)
+		}
+	}()
+	return control.NewClient(path), d
+}
+
+func (d *fakeDaemon) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req control.Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+		d.mu.Lock()
+		d.requests = append(d.requests, req)
+		reply := d.reply
+		d.mu.Unlock()
+		json.NewEncoder(conn).Encode(reply)
+	}
+}
+
+// Requests returns the requests received so far
+func (d *fakeDaemon) Requests() []control.Request {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]control.Request(nil), d.requests...)
+}
+
+func TestClientCommands(t *testing.T) {
+	status := timer.Status{State: timer.StateWorking, Remaining: 25 * time.Minute, Total: 50 * time.Minute, Cycle: 2}
+	tests := []struct {
+		name string
+		args []string
+		sent control.Request
+		out  string
+	}{
+		{"start", []string{"start"}, control.Request{Command: control.CmdStart}, "working 25:00\n"},
+		{"start with work", []string{"start", "--work", "30"}, control.Request{Command: control.CmdStart, Work: 30 * time.Minute}, "working 25:00\n"},
+		{"start with work=", []string{"start", "-work=5"}, control.Request{Command: control.CmdStart, Work: 5 * time.Minute}, "working 25:00\n"},
+		{"stop", []string{"stop"}, control.Request{Command: control.CmdStop}, "working 25:00\n"},
+		{"extend", []string{"extend"}, control.Request{Command: control.CmdExtend}, "working 25:00\n"},
+		{"pause", []string{"pause"}, control.Request{Command: control.CmdPause}, "working 25:00\n"},
+		{"resume", []string{"resume"}, control.Request{Command: control.CmdResume}, "working 25:00\n"},
+		{"status", []string{"status"}, control.Request{Command: control.CmdStatus}, "working 25:00\n"},
+		{
+			"status as JSON",
+			[]string{"status", "--json"},
+			control.Request{Command: control.CmdStatus},
+			`{"state":"working","remaining":"25:00","remaining_seconds":1500,"total_seconds":3000,"paused":false,"extend_used":false,"cycle":2}` + "\n",
+		},
+		{
+			"status with a template",
+			[]string{"status", "--format", "{{.State}} {{.RemainingSeconds}}/{{.TotalSeconds}} #{{.Cycle}}"},
+			control.Request{Command: control.CmdStatus},
+			"working 1500/3000 #2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, daemon := newFakeDaemon(t, control.Response{OK: true, Status: &status})
+			var out strings.Builder
+			if err := clientCommands[tt.args[0]](c, tt.args[1:], &out); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := daemon.Requests(); !reflect.DeepEqual(got, []control.Request{tt.sent}) {
+				t.Fatalf("sent %+v, want %+v", got, tt.sent)
+			}
+			if out.String() != tt.out {
+				t.Fatalf("printed %q, want %q", out.String(), tt.out)
+			}
+		})
+	}
+}
+
+func TestClientCommandErrors(t *testing.T) {
+	status := timer.Status{State: timer.StateWorking, Remaining: 25 * time.Minute}
+	tests := []struct {
+		name  string
+		args  []string
+		usage bool   // Rejected by the flag package, before anything is sent
+		msg   string // Part of the error
+	}{
+		{"work not a number", []string{"start", "--work", "soon"}, true, `invalid value "soon"`},
+		{"unknown flag", []string{"status", "--yaml"}, true, "flag provided but not defined"},
+		{"help", []string{"start", "-h"}, true, flag.ErrHelp.Error()},
+		{"bad template", []string{"status", "--format", "{{.State"}, false, "invalid --format"},
+		{"unknown field", []string{"status", "--format", "{{.Mood}}"}, false, "Mood"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, daemon := newFakeDaemon(t, control.Response{OK: true, Status: &status})
+			var out strings.Builder
+			err := clientCommands[tt.args[0]](c, tt.args[1:], &out)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.msg)
+			}
+
+			var usage usageError
+			if got := errors.As(err, &usage); got != tt.usage {
+				t.Fatalf("left to the flag package to report: %v, want %v", got, tt.usage)
+			}
+			if n := len(daemon.Requests()); tt.usage && n != 0 {
+				t.Fatalf("sent %d requests for a bad command line", n)
+			}
+		})
+	}
+}
+
+func TestClientCommandRefused(t *testing.T) {
+	status := timer.Status{State: timer.StateWorking, Remaining: 25 * time.Minute}
+	c, _ := newFakeDaemon(t, control.Response{Error: "cannot extend while working", Status: &status})
+
+	var out strings.Builder
+	err := clientCommands["extend"](c, nil, &out)
+	if err == nil || err.Error() != "cannot extend while working" {
+		t.Fatalf("extend = %v, want the refusal", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("printed %q for a refusal", out.String())
+	}
+}
+
+func TestClientWithoutDaemon(t *testing.T) {
+	c := control.NewClient(filepath.Join(t.TempDir(), "pomoduru.sock"))
+	var out strings.Builder
+	// Which runClient reports as pomoduru not running
+	err := clientCommands["status"](c, nil, &out)
+	if !errors.Is(err, syscall.ENOENT) {
+		t.Fatalf("status with no daemon = %v, want ENOENT", err)
+	}
+}
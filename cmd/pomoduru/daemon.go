@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/control"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// runDaemon runs the timer, scheduler and control socket without a TUI
+// until SIGTERM or SIGINT, reloading the config when its file is saved or
+// on SIGHUP. Transitions are logged to stdout, which journald picks up
+// under systemd.
+func runDaemon(cfg *config.Config, dryRun bool) {
+	// journald adds its own timestamps
+	logger := log.New(os.Stdout, "", 0)
+
+	t := newTimer(cfg, dryRun, timer.WithLogger(logger))
+
+	srv, err := control.Listen(config.SocketPath(), t)
+	if err != nil {
+		logger.Fatalf("Error opening control socket: %v", err)
+	}
+	events, cancel := t.Subscribe()
+	defer cancel()
+	go logEvents(logger, events)
+
+	go func() {
+		if err := srv.Serve(); err != nil {
+			logger.Printf("Control socket stopped: %v", err)
+		}
+	}()
+
+	scheduler := timer.NewScheduler(cfg, t)
+	scheduler.Start(context.Background())
+
+	if err := watchConfig(context.Background(), t, scheduler); err != nil {
+		logger.Printf("%v; reload the config with SIGHUP instead", err)
+	}
+
+	st := t.Status()
+	logger.Printf("Pomoduru daemon started, listening on %s, timer %s", config.SocketPath(), st.State)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			logger.Printf("Received %s, reloading config", sig)
+			reloadConfig(t, scheduler)
+			continue
+		}
+
+		// The session is already saved; it is picked up again on restart
+		logger.Printf("Received %s, shutting down", sig)
+		scheduler.Stop()
+		srv.Close()
+		return
+	}
+}
+
+// logEvents logs every timer event until events is closed
+func logEvents(logger *log.Logger, events <-chan timer.Event) {
+	for ev := range events {
+		line := fmt.Sprintf("%s: %s (%s)", ev.Kind, ev.Status.State, ev.Status.Remaining.Round(time.Second))
+		if ev.Message != "" {
+			line += " - " + ev.Message
+		}
+		logger.Print(line)
+	}
+}
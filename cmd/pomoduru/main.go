@@ -1,36 +1,133 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/control"
+	"github.com/aniketvish/pomoduru/internal/history"
+	"github.com/aniketvish/pomoduru/internal/ical"
+	"github.com/aniketvish/pomoduru/internal/stats"
 	"github.com/aniketvish/pomoduru/internal/timer"
 	"github.com/aniketvish/pomoduru/internal/ui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if _, ok := clientCommands[os.Args[1]]; ok {
+			runClient(os.Args[1], os.Args[2:])
+		}
+		if os.Args[1] == "stats" {
+			// Reads the history, so needs no running pomoduru
+			if err := stats.Run("pomoduru stats", os.Args[2:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	daemon := flag.Bool("daemon", false, "Run the timer without the TUI, e.g. as a systemd service")
+	dryRun := flag.Bool("dry-run", false, "Record notifications and suspends instead of performing them")
+	flag.Usage = printUsage
+	flag.Parse()
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	t := timer.NewTimer(cfg)
+	if *daemon {
+		runDaemon(cfg, *dryRun)
+		return
+	}
+
+	// If a daemon owns the timer, attach to it instead of starting another
+	client := control.NewClient(config.SocketPath())
+	if _, err := client.Status(); err == nil {
+		runTUI(cfg, ui.Remote(client))
+		return
+	}
+
+	t := newTimer(cfg, *dryRun)
+
+	// Let other processes drive the timer through the control socket
+	srv, err := control.Listen(config.SocketPath(), t)
+	if err != nil {
+		fmt.Printf("Error opening control socket: %v\n", err)
+		os.Exit(1)
+	}
+	go srv.Serve()
+	defer srv.Close()
 
 	// Create and start scheduler if enabled
 	scheduler := timer.NewScheduler(cfg, t)
-	scheduler.Start()
+	scheduler.Start(context.Background())
 	defer scheduler.Stop()
 
+	// Without the daemon's log, a failure only means edits apply on restart
+	watchConfig(context.Background(), t, scheduler)
+
+	runTUI(cfg, ui.Local(t))
+}
+
+// newTimer creates the timer with its state file, history and calendars
+func newTimer(cfg *config.Config, dryRun bool, opts ...timer.Option) *timer.Timer {
+	opts = append(opts,
+		timer.WithStateFile(config.StatePath()),
+		timer.WithHistory(history.Open(config.HistoryPath())),
+	)
+	if len(cfg.Calendars) > 0 {
+		opts = append(opts, timer.WithCalendar(ical.OpenFiles(cfg.Calendars...)))
+	}
+	if dryRun {
+		opts = append(opts, timer.WithActions(&timer.RecordingActions{}))
+	}
+	return timer.NewTimer(cfg, opts...)
+}
+
+// runTUI runs the interactive interface until the user quits
+func runTUI(cfg *config.Config, ctl ui.Controller) {
 	// Create UI model
-	model := ui.NewModel(cfg, t)
+	model := ui.NewModel(cfg, ctl)
 
-	// Start the TUI
+	// Start the TUI, fed by the timer's events
 	p := tea.NewProgram(model, tea.WithAltScreen())
+	events, cancel, err := ctl.Subscribe()
+	if err != nil {
+		fmt.Printf("Error subscribing to timer: %v\n", err)
+		os.Exit(1)
+	}
+	defer cancel()
+	go ui.Forward(p, events)
+
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func printUsage() {
+	fmt.Println("Pomoduru - Smart Pomodoro Timer")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  pomoduru [--dry-run]                          - Run the timer with its TUI, or attach to the daemon")
+	fmt.Println("  pomoduru --daemon [--dry-run]                 - Run the timer headless")
+	fmt.Println("  pomoduru start [--work minutes]               - Start a work session")
+	fmt.Println("  pomoduru stop                                 - Stop the timer")
+	fmt.Println("  pomoduru extend                               - Extend the current session (fails if not allowed)")
+	fmt.Println("  pomoduru pause | resume                       - Pause or resume the current session")
+	fmt.Println("  pomoduru status [--json | --format template]  - Show state and remaining time")
+	fmt.Println("  pomoduru stats [--by period] [--json]         - Show statistics from the history (see pomoduru stats --help)")
+	fmt.Println()
+	fmt.Println("The subcommands other than stats talk to a running pomoduru through its control socket.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  pomoduru start --work 25")
+	fmt.Println("  pomoduru status --format '{{.State}} {{.Remaining}}'")
+}
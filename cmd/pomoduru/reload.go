@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// reloading keeps reloads from the watcher and from SIGHUP apart
+var reloading sync.Mutex
+
+// watchConfig reloads the config whenever its file is saved, until ctx is
+// done
+func watchConfig(ctx context.Context, t *timer.Timer, scheduler *timer.Scheduler) error {
+	saved, err := config.Watch(ctx, config.ConfigPath())
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for range saved {
+			reloadConfig(t, scheduler)
+		}
+	}()
+	return nil
+}
+
+// reloadConfig loads the config file again and applies it to t and
+// scheduler. A config that can't be loaded or doesn't pass validation is
+// reported, and the current one kept.
+func reloadConfig(t *timer.Timer, scheduler *timer.Scheduler) {
+	reloading.Lock()
+	defer reloading.Unlock()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.RejectConfig(err)
+		return
+	}
+	if len(t.Reconfigure(cfg)) > 0 {
+		scheduler.Reconfigure(cfg)
+	}
+}
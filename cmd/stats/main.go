@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aniketvish/pomoduru/internal/stats"
+)
+
+func main() {
+	if err := stats.Run("pomoduru-stats", os.Args[1:]); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
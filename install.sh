@@ -8,13 +8,16 @@ echo "🍅 Installing Pomoduru..."
 echo "Building binaries..."
 go build -o pomoduru ./cmd/pomoduru
 go build -o pomoduru-config ./cmd/config
+go build -o pomoduru-stats ./cmd/stats
 
 # Install binaries
 echo "Installing binaries to /usr/local/bin..."
 sudo cp pomoduru /usr/local/bin/
 sudo cp pomoduru-config /usr/local/bin/
+sudo cp pomoduru-stats /usr/local/bin/
 sudo chmod +x /usr/local/bin/pomoduru
 sudo chmod +x /usr/local/bin/pomoduru-config
+sudo chmod +x /usr/local/bin/pomoduru-stats
 
 # Install systemd service
 echo "Installing systemd service..."
@@ -35,6 +38,9 @@ echo ""
 echo "To configure Pomoduru:"
 echo "  pomoduru-config interactive"
 echo ""
+echo "To see your focus statistics:"
+echo "  pomoduru stats --by week"
+echo ""
 echo "To run interactively:"
 echo "  pomoduru"
 echo ""
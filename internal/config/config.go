@@ -2,45 +2,186 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
 // Config holds all pomodoro configuration
 type Config struct {
-	WorkDuration    time.Duration `json:"work_duration"`     // Work period duration
-	BreakDuration   time.Duration `json:"break_duration"`    // Break duration after work
-	WarningTime     time.Duration `json:"warning_time"`      // Warning time before sleep
-	ExtendDuration  time.Duration `json:"extend_duration"`   // How long extension lasts
-	AlwaysOn        bool          `json:"always_on"`         // Keep timer running continuously
-	ScheduleEnabled bool          `json:"schedule_enabled"`  // Enable scheduled start times
-	ScheduleStart   string        `json:"schedule_start"`    // Start time (HH:MM format)
-	ScheduleEnd     string        `json:"schedule_end"`      // End time (HH:MM format)
+	Version           int           `json:"version"`                 // Format of the file (see CurrentVersion)
+	WorkDuration      time.Duration `json:"work_duration"`           // Work period duration
+	BreakDuration     time.Duration `json:"break_duration"`          // Break duration after work
+	LongBreakDuration time.Duration `json:"long_break_duration"`     // Break duration after every LongBreakEvery work sessions
+	LongBreakEvery    int           `json:"long_break_every"`        // Work sessions per long break (0 = never)
+	WarningTime       time.Duration `json:"warning_time"`            // Warning time before sleep
+	ExtendDuration    time.Duration `json:"extend_duration"`         // How long extension lasts
+	MaxPauseDuration  time.Duration `json:"max_pause_duration"`      // Total pause allowed per cycle (0 = unlimited)
+	EndAction         string        `json:"end_action"`              // What happens when a work session ends
+	EndActionArgs     []string      `json:"end_action_args"`         // Extra arguments for EndAction
+	AlwaysOn          bool          `json:"always_on"`               // Keep timer running continuously
+	ScheduleEnabled   bool          `json:"schedule_enabled"`        // Enable scheduled start times
+	ScheduleStart     string        `json:"schedule_start"`          // Start time (HH:MM format)
+	ScheduleEnd       string        `json:"schedule_end"`            // End time (HH:MM format)
+	Schedule          Schedule      `json:"schedule"`                // Weekly windows, replacing ScheduleStart/ScheduleEnd when set
+	EndOfDay          string        `json:"end_of_day,omitempty"`    // What happens to work under way when a schedule window ends
+	ScheduleCron      []string      `json:"schedule_cron,omitempty"` // Cron expressions starting work sessions, replacing the windows when set
+	Calendars         []string      `json:"calendars,omitempty"`     // .ics files whose busy times work sessions end before
+	Timezone          string        `json:"timezone,omitempty"`      // IANA zone the schedule is in, e.g. Europe/Berlin (default local time)
+}
+
+// End-of-work actions
+const (
+	ActionSuspend     = "suspend"      // systemctl suspend
+	ActionHibernate   = "hibernate"    // systemctl hibernate
+	ActionHybridSleep = "hybrid-sleep" // systemctl hybrid-sleep
+	ActionLock        = "lock"         // loginctl lock-session
+	ActionBlank       = "blank"        // Turn the display off
+	ActionOverlay     = "overlay"      // Fullscreen break overlay in the TUI only
+	ActionCommand     = "command"      // Run EndActionArgs as a command
+)
+
+// EndActions lists every supported end-of-work action
+var EndActions = []string{
+	ActionSuspend,
+	ActionHibernate,
+	ActionHybridSleep,
+	ActionLock,
+	ActionBlank,
+	ActionOverlay,
+	ActionCommand,
+}
+
+// CheckEndAction reports whether action is supported and has the
+// arguments it needs
+func CheckEndAction(action string, args []string) error {
+	for _, a := range EndActions {
+		if a != action {
+			continue
+		}
+		if action == ActionCommand && len(args) == 0 {
+			return fmt.Errorf("end action %q needs a command to run", action)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown end action %q (want one of %v)", action, EndActions)
+}
+
+// End-of-day policies, for work under way when a schedule window ends
+const (
+	EndOfDayFinish = "finish" // Let the pomodoro and the break after it run out, then stop
+	EndOfDayBreak  = "break"  // Stop when the next break would begin
+	EndOfDayNow    = "now"    // Stop straight away
+)
+
+// EndOfDayPolicies lists every supported end-of-day policy
+var EndOfDayPolicies = []string{
+	EndOfDayFinish,
+	EndOfDayBreak,
+	EndOfDayNow,
+}
+
+// CheckEndOfDay reports whether policy is supported. Empty means the
+// default, EndOfDayFinish.
+func CheckEndOfDay(policy string) error {
+	if policy == "" || slices.Contains(EndOfDayPolicies, policy) {
+		return nil
+	}
+	return fmt.Errorf("unknown end-of-day policy %q (want one of %v)", policy, EndOfDayPolicies)
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		WorkDuration:    50 * time.Minute,
-		BreakDuration:   10 * time.Minute,
-		WarningTime:     5 * time.Minute,
-		ExtendDuration:  5 * time.Minute,
-		AlwaysOn:        false,
-		ScheduleEnabled: false,
-		ScheduleStart:   "09:00",
-		ScheduleEnd:     "18:00",
+		Version:           CurrentVersion,
+		WorkDuration:      50 * time.Minute,
+		BreakDuration:     10 * time.Minute,
+		LongBreakDuration: 30 * time.Minute,
+		LongBreakEvery:    4,
+		WarningTime:       5 * time.Minute,
+		ExtendDuration:    5 * time.Minute,
+		MaxPauseDuration:  0,
+		EndAction:         ActionSuspend,
+		AlwaysOn:          false,
+		ScheduleEnabled:   false,
+		ScheduleStart:     "09:00",
+		ScheduleEnd:       "18:00",
+		EndOfDay:          EndOfDayFinish,
 	}
 }
 
+// Location returns the time zone schedule times are read in: Timezone if
+// set, otherwise local time
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local, fmt.Errorf("unknown timezone %q", c.Timezone)
+	}
+	return loc, nil
+}
+
 // ConfigPath returns the path to the config file
 func ConfigPath() string {
 	homeDir, _ := os.UserHomeDir()
 	return filepath.Join(homeDir, ".config", "pomoduru", "config.json")
 }
 
-// LoadConfig loads configuration from file, creates default if not exists
+// StatePath returns the path to the file holding the in-flight session
+func StatePath() string {
+	return filepath.Join(filepath.Dir(ConfigPath()), "state.json")
+}
+
+// SocketPath returns the path of the control socket of a running
+// pomoduru, under $XDG_RUNTIME_DIR, or else in a directory of the user's
+// own under /tmp
+func SocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pomoduru.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("pomoduru-%d", os.Getuid()), "pomoduru.sock")
+}
+
+// DataDir returns the directory holding pomoduru's data files, following
+// $XDG_DATA_HOME
+func DataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "pomoduru")
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "share", "pomoduru")
+}
+
+// HistoryPath returns the path to the session history log
+func HistoryPath() string {
+	return filepath.Join(DataDir(), "history.jsonl")
+}
+
+// LoadConfig loads configuration from file, creates default if not exists.
+// Settings that don't pass Validate are an error. A file that can't be
+// parsed at all is replaced by the last known good copy, if there is one.
 func LoadConfig() (*Config, error) {
+	config, err := LoadConfigUnchecked()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigPath(), err)
+	}
+	
+	// Not being able to keep a copy doesn't stop this one being used
+	keepGood(ConfigPath(), config)
+	return config, nil
+}
+
+// LoadConfigUnchecked is LoadConfig without validation, so that a config
+// with bad settings can still be shown and fixed
+func LoadConfigUnchecked() (*Config, error) {
 	configPath := ConfigPath()
 	
 	// Create config directory if it doesn't exist
@@ -58,32 +199,83 @@ func LoadConfig() (*Config, error) {
 		return config, nil
 	}
 	
-	// Load existing config
-	file, err := os.Open(configPath)
+	// Load existing config, upgrading older files
+	config, version, err := readConfig(configPath)
+	var corrupt *corruptError
+	if errors.As(err, &corrupt) {
+		config, err = recoverConfig(configPath, err)
+		version = CurrentVersion
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	if version < CurrentVersion {
+		// The upgraded settings are used either way; the file is
+		// upgraded on a later load if it can't be now
+		upgradeFile(configPath, version, config)
+	}
 	
-	var config Config
-	if err := json.NewDecoder(file).Decode(&config); err != nil {
-		return nil, err
+	return config, nil
+}
+
+// ReadConfig reads the config file at path, without validating it. Files
+// in an older format are upgraded in memory only.
+func ReadConfig(path string) (*Config, error) {
+	config, _, err := readConfig(path)
+	return config, err
+}
+
+// readConfig reads the config file at path, returning it upgraded to
+// CurrentVersion along with the version the file is at
+func readConfig(path string) (*Config, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	data, version, err := migrate(data)
+	if err != nil {
+		// A file from a newer pomoduru isn't corrupt
+		if version <= CurrentVersion {
+			err = &corruptError{err}
+		}
+		return nil, version, fmt.Errorf("%s: %w", path, err)
+	}
+	
+	// Start from defaults so settings missing from older files keep them,
+	// except that a file from before long breaks keeps them off
+	config := DefaultConfig()
+	config.LongBreakEvery = 0
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, version, fmt.Errorf("%s: %w", path, &corruptError{err})
 	}
 	
-	return &config, nil
+	return config, version, nil
 }
 
-// SaveConfig saves configuration to file
+// SaveConfig saves configuration to file, refusing settings that don't
+// pass Validate. The file is replaced atomically and becomes the last
+// known good copy.
 func SaveConfig(config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	
 	configPath := ConfigPath()
+	if err := writeConfig(configPath, config); err != nil {
+		return err
+	}
+	return keepGood(configPath, config)
+}
+
+// writeConfig atomically replaces the file at path with config, in the
+// current format
+func writeConfig(path string, config *Config) error {
+	config.Version = CurrentVersion
 	
-	file, err := os.Create(configPath)
+	data, err := encodeConfig(config)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(config)
+	return writeFile(path, data, 0644)
 }
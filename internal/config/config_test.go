@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLongBreaksOffWhenMissing(t *testing.T) {
+	path := installConfig(t, "v0.json")
+
+	// A current file that doesn't mention long breaks
+	if err := os.WriteFile(path, []byte(`{"version": 1, "work_duration": "25m"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LongBreakEvery != 0 {
+		t.Fatalf("long_break_every = %d, want 0 when missing", cfg.LongBreakEvery)
+	}
+
+	// A new config starts with them on
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := DefaultConfig().LongBreakEvery; cfg.LongBreakEvery != want || want == 0 {
+		t.Fatalf("new config long_break_every = %d, want the default %d", cfg.LongBreakEvery, want)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := SocketPath(); got != "/run/user/1000/pomoduru.sock" {
+		t.Fatalf("socket = %s, want it in the runtime directory", got)
+	}
+
+	// A directory of the user's own, not a name anyone could take in /tmp
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	want := filepath.Join(os.TempDir(), fmt.Sprintf("pomoduru-%d", os.Getuid()), "pomoduru.sock")
+	if got := SocketPath(); got != want {
+		t.Fatalf("socket = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// duration is a time.Duration written to config.json as a string such as
+// "50m" or "1h15m". Older files held nanosecond counts, which are still
+// read; they are written as strings on the next save.
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(formatDuration(time.Duration(d)))
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// The old format
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s: want a string such as \"50m\"", data)
+		}
+		*d = duration(n)
+		return nil
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: want a string such as \"50m\" or \"1h15m\"", s)
+	}
+	*d = duration(v)
+	return nil
+}
+
+// formatDuration formats d like time.Duration.String, without the zero
+// units it ends in: 50m rather than 50m0s, 1h rather than 1h0m0s
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// plainConfig has Config's fields without its methods, so encoding and
+// decoding it doesn't come back to them
+type plainConfig Config
+
+// jsonConfig is Config as config.json has it: its durations as strings,
+// and its other settings from the embedded plainConfig. The fields point
+// into the Config, so decoding fills it in directly; the version is only
+// here to stay at the top of the file.
+type jsonConfig struct {
+	Version           *int      `json:"version"`
+	WorkDuration      *duration `json:"work_duration"`
+	BreakDuration     *duration `json:"break_duration"`
+	LongBreakDuration *duration `json:"long_break_duration"`
+	WarningTime       *duration `json:"warning_time"`
+	ExtendDuration    *duration `json:"extend_duration"`
+	MaxPauseDuration  *duration `json:"max_pause_duration"`
+	*plainConfig
+}
+
+// asJSON returns the jsonConfig sharing c's settings
+func (c *Config) asJSON() *jsonConfig {
+	return &jsonConfig{
+		Version:           &c.Version,
+		WorkDuration:      (*duration)(&c.WorkDuration),
+		BreakDuration:     (*duration)(&c.BreakDuration),
+		LongBreakDuration: (*duration)(&c.LongBreakDuration),
+		WarningTime:       (*duration)(&c.WarningTime),
+		ExtendDuration:    (*duration)(&c.ExtendDuration),
+		MaxPauseDuration:  (*duration)(&c.MaxPauseDuration),
+		plainConfig:       (*plainConfig)(c),
+	}
+}
+
+// MarshalJSON writes the config with durations as strings
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.asJSON())
+}
+
+// UnmarshalJSON reads a config with durations as strings or, from older
+// files, nanosecond counts. Settings missing from data are left as they
+// were.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, c.asJSON())
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{50 * time.Minute, "50m"},
+		{time.Hour, "1h"},
+		{time.Hour + 15*time.Minute, "1h15m"},
+		{90 * time.Second, "1m30s"},
+		{0, "0s"},
+		{2*time.Hour + 30*time.Second, "2h0m30s"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WorkDuration = time.Hour + 15*time.Minute
+	cfg.WarningTime = 90 * time.Second
+	cfg.MaxPauseDuration = 0
+	cfg.LongBreakEvery = 3
+	cfg.EndAction = ActionCommand
+	cfg.EndActionArgs = []string{"xset", "dpms", "force", "off"}
+	cfg.Schedule = Schedule{
+		Windows:    []Window{{Days: []string{"mon", "fri"}, Start: "22:00", End: "02:00"}},
+		Exceptions: []string{"2025-12-25"},
+	}
+	cfg.ScheduleCron = []string{"0 9 * * mon-fri"}
+	cfg.AlwaysOn = true
+	cfg.EndOfDay = EndOfDayNow
+	cfg.Calendars = []string{"/home/me/work.ics"}
+	cfg.Timezone = "Europe/Berlin"
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"work_duration":"1h15m"`, `"warning_time":"1m30s"`, `"max_pause_duration":"0s"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s missing %s", data, want)
+		}
+	}
+
+	var got Config
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, cfg) {
+		t.Fatalf("round trip changed the config:\n got %+v\nwant %+v", got, *cfg)
+	}
+}
+
+func TestUnmarshalNanoseconds(t *testing.T) {
+	cfg := DefaultConfig()
+	data := `{"work_duration": 1500000000000, "break_duration": "5m", "warning_time": 0}`
+	if err := json.Unmarshal([]byte(data), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WorkDuration != 25*time.Minute || cfg.BreakDuration != 5*time.Minute || cfg.WarningTime != 0 {
+		t.Fatalf("read work %s, break %s, warning %s, want 25m, 5m, 0s",
+			cfg.WorkDuration, cfg.BreakDuration, cfg.WarningTime)
+	}
+	// Settings missing from the file keep their defaults
+	if want := DefaultConfig().ExtendDuration; cfg.ExtendDuration != want {
+		t.Fatalf("extend = %s, want the default %s", cfg.ExtendDuration, want)
+	}
+
+	err := json.Unmarshal([]byte(`{"work_duration": "fifty minutes"}`), cfg)
+	if err == nil || !strings.Contains(err.Error(), `invalid duration "fifty minutes"`) {
+		t.Fatalf("unreadable duration = %v, want an error quoting it", err)
+	}
+}
+
+func TestEveryDurationIsAString(t *testing.T) {
+	data, err := json.Marshal(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// A duration added to Config needs its place in jsonConfig too
+	typ := reflect.TypeFor[Config]()
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if f.Type != reflect.TypeFor[time.Duration]() {
+			continue
+		}
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if _, ok := settings[key].(string); !ok {
+			t.Errorf("%s written as %v, want a string", key, settings[key])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrLocked is returned by LockConfig when another process holds the lock
+// and it was asked not to wait
+var ErrLocked = errors.New("config is locked by another process")
+
+// LockConfig takes an advisory lock on the config file, for changes that
+// read it, modify it and save it again, and returns the function that
+// releases it. If wait is false and another process holds the lock, it
+// returns ErrLocked instead of waiting.
+func LockConfig(wait bool) (unlock func(), err error) {
+	path := ConfigPath() + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	// A file of its own, as saving replaces config.json with another
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	if err := syscall.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+// writeFile atomically replaces the file at path with data: it is written
+// to a temporary file beside it, which is then renamed over it, so that a
+// crash never leaves it half written. The file keeps its permissions, or
+// is given perm if it is new.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	perm = permOf(path, perm)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// permOf returns the permissions of the file at path, or perm if there is
+// no such file
+func permOf(path string, perm os.FileMode) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return perm
+}
+
+// encodeConfig returns config as written to config.json
+func encodeConfig(config *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// goodPath returns the path of the last known good copy of the config
+// file at path
+func goodPath(path string) string {
+	return path + ".good"
+}
+
+// keepGood makes config, which has passed Validate, the last known good
+// copy of the config file at path. A new copy is as private as the file.
+func keepGood(path string, config *Config) error {
+	data, err := encodeConfig(config)
+	if err != nil {
+		return err
+	}
+	if old, err := os.ReadFile(goodPath(path)); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return writeFile(goodPath(path), data, permOf(path, 0644))
+}
+
+// corruptError is returned by readConfig for a file that can't be parsed
+type corruptError struct {
+	err error
+}
+
+func (e *corruptError) Error() string {
+	return e.err.Error()
+}
+
+func (e *corruptError) Unwrap() error {
+	return e.err
+}
+
+// recoverConfig reads the last known good copy of the corrupt config file
+// at path, and puts it in its place. The corrupt file is copied beside it
+// first, with .corrupt added to its name; if that fails it is left where
+// it is. If there is no good copy, err is returned.
+func recoverConfig(path string, err error) (*Config, error) {
+	config, _, gerr := readConfig(goodPath(path))
+	if gerr != nil {
+		return nil, err
+	}
+
+	// The good copy is used either way. config.json is only replaced, never
+	// removed, so that a failure can't leave it missing and have the next
+	// load start again from defaults.
+	data, eerr := encodeConfig(config)
+	corrupt, rerr := os.ReadFile(path)
+	perm := permOf(path, 0644)
+	if eerr == nil && rerr == nil && writeFile(path+".corrupt", corrupt, perm) == nil {
+		writeFile(path, data, perm)
+	}
+	return config, nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expectMode fails the test unless the file at path has permissions perm
+func expectMode(t *testing.T, path string, perm os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != perm {
+		t.Fatalf("%s has mode %v, want %v", filepath.Base(path), got, perm)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := writeFile(path, []byte("first"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expectMode(t, path, 0600)
+
+	// Replaced by another file, so a reader of the old one sees it whole
+	old, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(path, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 16)
+	n, _ := old.Read(buf)
+	if got := string(buf[:n]); got != "first" {
+		t.Fatalf("old file reads %q, want %q", got, "first")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Fatalf("file holds %q, want %q", data, "second")
+	}
+	// Its permissions are kept, and no temporary file is left
+	expectMode(t, path, 0640)
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("%d files in the directory, want only config.json", len(entries))
+	}
+}
+
+func TestSaveConfigKeepsMode(t *testing.T) {
+	path := installConfig(t, "v0.json")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveConfig(DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	expectMode(t, path, 0600)
+	expectMode(t, goodPath(path), 0600)
+}
+
+func TestLockConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	unlock, err := LockConfig(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockConfig(false); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second lock = %v, want ErrLocked", err)
+	}
+
+	// A waiting lock is taken once the first is released
+	locked := make(chan func())
+	go func() {
+		unlock, err := LockConfig(true)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- unlock
+	}()
+	select {
+	case <-locked:
+		t.Fatal("lock taken while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock not taken once released")
+	}
+}
+
+func TestRecoverCorruptConfig(t *testing.T) {
+	path := installConfig(t, "v0.json")
+	good := DefaultConfig()
+	good.WorkDuration = 25 * time.Minute
+	if err := SaveConfig(good); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := []byte(`{"work_duration": "25m",`)
+	if err := os.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.WorkDuration != 25*time.Minute {
+		t.Fatalf("recovered work %s, want the good copy's 25m", cfg.WorkDuration)
+	}
+
+	// The good copy took the file's place, which is kept aside
+	if data, _ := os.ReadFile(path + ".corrupt"); string(data) != string(corrupt) {
+		t.Fatalf("corrupt copy holds %q, want the corrupt file", data)
+	}
+	expectMode(t, path+".corrupt", 0600)
+	if cfg, _, err := readConfig(path); err != nil || cfg.WorkDuration != 25*time.Minute {
+		t.Fatalf("config.json after recovery = %v, %v, want the good copy", cfg, err)
+	}
+}
+
+func TestRecoverWithoutCorruptCopy(t *testing.T) {
+	path := installConfig(t, "v0.json")
+	good := DefaultConfig()
+	good.WorkDuration = 25 * time.Minute
+	if err := SaveConfig(good); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Nowhere to put the corrupt file
+	if err := os.Mkdir(path+".corrupt", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path+".corrupt", "keep"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The good copy is used, and neither it nor config.json is lost
+	for range 2 {
+		cfg, err := LoadConfig()
+		if err != nil || cfg.WorkDuration != 25*time.Minute {
+			t.Fatalf("load = %v, %v, want the good copy", cfg, err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{" {
+		t.Fatalf("config.json holds %q, want it left as it was", data)
+	}
+	if cfg, _, err := readConfig(goodPath(path)); err != nil || cfg.WorkDuration != 25*time.Minute {
+		t.Fatalf("good copy = %v, %v, want it kept", cfg, err)
+	}
+}
+
+func TestCorruptConfigWithoutGoodCopy(t *testing.T) {
+	path := installConfig(t, "v0.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("load = %v, want an error naming %s", err, path)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Fatalf("corrupt file moved aside with nothing to replace it: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CurrentVersion is the version of the config.json format written by this
+// build. Files without a version are version 0.
+const CurrentVersion = 1
+
+// migrations[n] upgrades the settings of a version n file to version n+1.
+// They work on the raw settings rather than on Config, which only
+// describes the current version.
+var migrations = [CurrentVersion]func(settings map[string]json.RawMessage) error{
+	durationsToStrings, // 0 → 1
+}
+
+// migrate upgrades config.json data to CurrentVersion one version at a
+// time, returning the upgraded data and the version the data was at
+func migrate(data []byte) ([]byte, int, error) {
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if raw, ok := settings["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+			return nil, 0, fmt.Errorf("invalid version %s", raw)
+		}
+	}
+	switch {
+	case version > CurrentVersion:
+		return nil, version, fmt.Errorf("version %d is newer than this pomoduru understands (%d)", version, CurrentVersion)
+	case version == CurrentVersion:
+		return data, version, nil
+	}
+
+	if settings == nil {
+		settings = make(map[string]json.RawMessage)
+	}
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](settings); err != nil {
+			return nil, version, fmt.Errorf("upgrading from version %d: %w", v, err)
+		}
+	}
+	settings["version"], _ = json.Marshal(CurrentVersion)
+
+	data, err := json.Marshal(settings)
+	return data, version, err
+}
+
+// durationsToStrings upgrades version 0, which held durations as
+// nanosecond counts. Files written just before versions were introduced
+// already have strings, which are kept.
+func durationsToStrings(settings map[string]json.RawMessage) error {
+	keys := []string{
+		"work_duration",
+		"break_duration",
+		"long_break_duration",
+		"warning_time",
+		"extend_duration",
+		"max_pause_duration",
+	}
+	for _, key := range keys {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		settings[key], _ = json.Marshal(formatDuration(time.Duration(n)))
+	}
+	return nil
+}
+
+// backupPath returns where the original of a config file upgraded from
+// version is kept
+func backupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// upgradeFile rewrites the config file at path, at version before, with
+// the upgraded config, having first copied the original to backupPath. An
+// existing backup is left as it is. It gives up without touching the file
+// if the backup can't be written.
+func upgradeFile(path string, version int, config *Config) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	backup, err := os.OpenFile(backupPath(path, version), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	switch {
+	case os.IsExist(err):
+	case err != nil:
+		return err
+	default:
+		_, err := backup.Write(original)
+		if cerr := backup.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeConfig(path, config)
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installConfig points ConfigPath into a temporary home directory and
+// copies the fixture name from testdata there, returning the path
+func installConfig(t *testing.T, name string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := ConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUpgrade(t *testing.T) {
+	v0 := DefaultConfig()
+	v0.WorkDuration = 25 * time.Minute
+	v0.BreakDuration = 5 * time.Minute
+	v0.WarningTime = 2 * time.Minute
+	v0.ExtendDuration = 10 * time.Minute
+	v0.AlwaysOn = true
+	v0.ScheduleEnabled = true
+	v0.ScheduleStart = "08:30"
+	v0.ScheduleEnd = "17:00"
+	v0.LongBreakEvery = 0 // Long breaks came later, and stay off
+
+	unversioned := DefaultConfig()
+	unversioned.WorkDuration = 45 * time.Minute
+	unversioned.BreakDuration = 15 * time.Minute
+	unversioned.LongBreakDuration = 40 * time.Minute
+	unversioned.LongBreakEvery = 3
+	unversioned.WarningTime = 3 * time.Minute
+	unversioned.ExtendDuration = 10 * time.Minute
+	unversioned.MaxPauseDuration = 20 * time.Minute
+	unversioned.EndAction = ActionLock
+	unversioned.ScheduleEnabled = true
+	unversioned.Schedule = Schedule{Windows: []Window{{
+		Days:  []string{"mon", "tue", "wed", "thu", "fri"},
+		Start: "09:00",
+		End:   "17:30",
+	}}}
+
+	tests := []struct {
+		file string
+		want *Config
+	}{
+		{"v0.json", v0},
+		{"unversioned.json", unversioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := installConfig(t, tt.file)
+			original, _ := os.ReadFile(path)
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Fatalf("loaded %+v\nwant %+v", *cfg, *tt.want)
+			}
+
+			backup, err := os.ReadFile(path + ".v0.bak")
+			if err != nil || string(backup) != string(original) {
+				t.Fatalf("backup = %q, %v, want the original file", backup, err)
+			}
+
+			// The file itself is upgraded, and reads back the same
+			upgraded, version, err := readConfig(path)
+			if err != nil || version != CurrentVersion {
+				t.Fatalf("upgraded file at version %d, %v, want %d", version, err, CurrentVersion)
+			}
+			if !reflect.DeepEqual(upgraded, tt.want) {
+				t.Fatalf("upgraded file holds %+v\nwant %+v", *upgraded, *tt.want)
+			}
+		})
+	}
+}
+
+func TestNewerVersionRejected(t *testing.T) {
+	path := installConfig(t, "unversioned.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "work_duration": "25m"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A good copy that recovery would fall back to
+	if err := writeFile(goodPath(path), []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "newer than this pomoduru understands") {
+		t.Fatalf("load = %v, want the version refused", err)
+	}
+
+	// The file is left alone for the newer pomoduru
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Fatalf("newer file treated as corrupt: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version": 99`) {
+		t.Fatalf("newer file replaced by %s", data)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schedule is a weekly timetable of working windows. Days listed in
+// Exceptions, such as holidays, have no windows at all.
+type Schedule struct {
+	Windows    []Window `json:"windows,omitempty"`
+	Exceptions []string `json:"exceptions,omitempty"` // Dates off (YYYY-MM-DD)
+}
+
+// Window is a span of working time on some days of the week
+type Window struct {
+	Days  []string `json:"days"`  // Weekdays the window applies to: mon, tue, ... sun
+	Start string   `json:"start"` // Start time (HH:MM format)
+	End   string   `json:"end"`   // End time (HH:MM format)
+}
+
+// DateLayout is the format of exception dates
+const DateLayout = "2006-01-02"
+
+// dayNames are the short weekday names used in windows, indexed by
+// time.Weekday
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// dayAliases are shorthands accepted by ParseDays
+var dayAliases = map[string]string{
+	"daily":    "mon-sun",
+	"weekdays": "mon-fri",
+	"weekends": "sat,sun",
+}
+
+// ParseDays parses a comma-separated list of weekdays and ranges, such as
+// "mon-fri", "sat,sun" or "weekdays", into the days of a Window, in week
+// order starting on Monday
+func ParseDays(spec string) ([]string, error) {
+	if alias, ok := dayAliases[strings.ToLower(strings.TrimSpace(spec))]; ok {
+		spec = alias
+	}
+
+	var set [7]bool
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, err := parseDay(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return nil, err
+			}
+		}
+
+		// Ranges may wrap around the end of the week, as in fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			set[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	var days []string
+	for i := range 7 {
+		d := (i + 1) % 7 // Monday first
+		if set[d] {
+			days = append(days, dayNames[d])
+		}
+	}
+	return days, nil
+}
+
+func parseDay(name string) (time.Weekday, error) {
+	for i, n := range dayNames {
+		if name == n || name == strings.ToLower(time.Weekday(i).String()) {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q (want mon, tue, wed, thu, fri, sat or sun)", name)
+}
+
+// ParseClock parses an HH:MM time of day into the time since midnight
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// On reports whether the window applies on day
+func (w Window) On(day time.Weekday) bool {
+	return slices.Contains(w.Days, dayNames[day])
+}
+
+// Check reports whether the window's days and times are well formed
+func (w Window) Check() error {
+	if len(w.Days) == 0 {
+		return fmt.Errorf("window %s-%s has no days", w.Start, w.End)
+	}
+	for _, d := range w.Days {
+		if !slices.Contains(dayNames, d) {
+			return fmt.Errorf("window %s-%s: unknown day %q", w.Start, w.End, d)
+		}
+	}
+	if _, err := ParseClock(w.Start); err != nil {
+		return err
+	}
+	if _, err := ParseClock(w.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String formats the window as in "mon,tue,wed 09:00-12:30"
+func (w Window) String() string {
+	return fmt.Sprintf("%s %s-%s", strings.Join(w.Days, ","), w.Start, w.End)
+}
+
+// IsException reports whether the date of t is a day off
+func (s Schedule) IsException(t time.Time) bool {
+	return slices.Contains(s.Exceptions, t.Format(DateLayout))
+}
+
+// WeeklySchedule returns the schedule in effect: the weekly windows if
+// any are set, otherwise ScheduleStart to ScheduleEnd on every day
+func (c *Config) WeeklySchedule() Schedule {
+	if len(c.Schedule.Windows) > 0 {
+		return c.Schedule
+	}
+
+	s := c.Schedule
+	s.Windows = []Window{{
+		Days:  []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"},
+		Start: c.ScheduleStart,
+		End:   c.ScheduleEnd,
+	}}
+	return s
+}
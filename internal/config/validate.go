@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/cron"
+)
+
+// FieldError is a problem with one setting
+type FieldError struct {
+	Field   string // Path of the setting in config.json, such as schedule.windows[1]
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every problem Validate found
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "invalid settings: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks that every setting makes sense, returning a
+// ValidationError listing all the problems found, or nil
+func (c *Config) Validate() error {
+	var errs ValidationError
+	check := func(field string, ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	checkErr := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, FieldError{Field: field, Message: err.Error()})
+		}
+	}
+
+	check("work_duration", c.WorkDuration > 0, "must be positive, not %s", c.WorkDuration)
+	check("break_duration", c.BreakDuration > 0, "must be positive, not %s", c.BreakDuration)
+	check("long_break_every", c.LongBreakEvery >= 0, "must not be negative, not %d", c.LongBreakEvery)
+	if c.LongBreakEvery > 0 {
+		check("long_break_duration", c.LongBreakDuration > 0, "must be positive, not %s", c.LongBreakDuration)
+	}
+	check("warning_time", c.WarningTime >= 0, "must not be negative, not %s", c.WarningTime)
+	if c.WarningTime > 0 && c.WorkDuration > 0 {
+		check("warning_time", c.WarningTime < c.WorkDuration, "%s must be shorter than work_duration (%s)", c.WarningTime, c.WorkDuration)
+	}
+	check("extend_duration", c.ExtendDuration > 0, "must be positive, not %s", c.ExtendDuration)
+	check("max_pause_duration", c.MaxPauseDuration >= 0, "must not be negative, not %s", c.MaxPauseDuration)
+	checkErr("end_action", CheckEndAction(c.EndAction, c.EndActionArgs))
+
+	_, err := ParseClock(c.ScheduleStart)
+	checkErr("schedule_start", err)
+	_, err = ParseClock(c.ScheduleEnd)
+	checkErr("schedule_end", err)
+	for i, w := range c.Schedule.Windows {
+		checkErr(fmt.Sprintf("schedule.windows[%d]", i), w.Check())
+	}
+	for i, date := range c.Schedule.Exceptions {
+		_, err := time.Parse(DateLayout, date)
+		check(fmt.Sprintf("schedule.exceptions[%d]", i), err == nil, "invalid date %q: want YYYY-MM-DD", date)
+	}
+	for i, expr := range c.ScheduleCron {
+		_, err := cron.Parse(expr)
+		checkErr(fmt.Sprintf("schedule_cron[%d]", i), err)
+	}
+	checkErr("end_of_day", CheckEndOfDay(c.EndOfDay))
+	for i, path := range c.Calendars {
+		check(fmt.Sprintf("calendars[%d]", i), filepath.IsAbs(path), "%q must be an absolute path", path)
+	}
+	_, err = c.Location()
+	checkErr("timezone", err)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		field  string // Of the one FieldError wanted, or "" for none
+		msg    string // Part of its message
+	}{
+		{"defaults", func(c *Config) {}, "", ""},
+		{"zero work", func(c *Config) { c.WorkDuration = 0 }, "work_duration", "must be positive, not 0s"},
+		{"negative break", func(c *Config) { c.BreakDuration = -time.Minute }, "break_duration", "must be positive, not -1m0s"},
+		{"zero long break", func(c *Config) { c.LongBreakDuration = 0 }, "long_break_duration", "must be positive"},
+		{"zero long break, never taken", func(c *Config) { c.LongBreakDuration, c.LongBreakEvery = 0, 0 }, "", ""},
+		{"negative long break count", func(c *Config) { c.LongBreakEvery = -1 }, "long_break_every", "must not be negative"},
+		{"negative warning", func(c *Config) { c.WarningTime = -time.Minute }, "warning_time", "must not be negative"},
+		{"no warning", func(c *Config) { c.WarningTime = 0 }, "", ""},
+		{"warning as long as work", func(c *Config) { c.WarningTime = c.WorkDuration }, "warning_time", "must be shorter than work_duration (50m0s)"},
+		{"zero extension", func(c *Config) { c.ExtendDuration = 0 }, "extend_duration", "must be positive"},
+		{"negative pause cap", func(c *Config) { c.MaxPauseDuration = -time.Second }, "max_pause_duration", "must not be negative"},
+		{"unknown end action", func(c *Config) { c.EndAction = "explode" }, "end_action", `unknown end action "explode"`},
+		{"command without arguments", func(c *Config) { c.EndAction = ActionCommand }, "end_action", "needs a command to run"},
+		{"bad start", func(c *Config) { c.ScheduleStart = "9am" }, "schedule_start", `invalid time "9am": want HH:MM`},
+		{"bad end", func(c *Config) { c.ScheduleEnd = "24:00" }, "schedule_end", `invalid time "24:00"`},
+		{
+			"bad window",
+			func(c *Config) {
+				c.Schedule.Windows = []Window{{Days: []string{"mon"}, Start: "09:00", End: "17:00"}, {Start: "09:00", End: "12:00"}}
+			},
+			"schedule.windows[1]", "has no days",
+		},
+		{"bad day off", func(c *Config) { c.Schedule.Exceptions = []string{"25/12/2025"} }, "schedule.exceptions[0]", "want YYYY-MM-DD"},
+		{"bad cron", func(c *Config) { c.ScheduleCron = []string{"0 9 * *"} }, "schedule_cron[0]", "want 5 fields"},
+		{"unknown end-of-day policy", func(c *Config) { c.EndOfDay = "later" }, "end_of_day", `unknown end-of-day policy "later"`},
+		{"relative calendar", func(c *Config) { c.Calendars = []string{"work.ics"} }, "calendars[0]", "must be an absolute path"},
+		{"unknown timezone", func(c *Config) { c.Timezone = "Mars/Olympus_Mons" }, "timezone", `unknown timezone "Mars/Olympus_Mons"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+
+			var verr ValidationError
+			if !errors.As(err, &verr) || len(verr) != 1 {
+				t.Fatalf("Validate = %v, want one problem", err)
+			}
+			if verr[0].Field != tt.field || !strings.Contains(verr[0].Message, tt.msg) {
+				t.Fatalf("problem = %q: %q, want %q containing %q", verr[0].Field, verr[0].Message, tt.field, tt.msg)
+			}
+		})
+	}
+}
+
+func TestValidationErrorFormat(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WorkDuration = 0
+	cfg.EndAction = "explode"
+	cfg.Timezone = "Nowhere"
+
+	err := cfg.Validate()
+	want := "invalid settings: work_duration: must be positive, not 0s; " +
+		`end_action: unknown end action "explode" (want one of [suspend hibernate hybrid-sleep lock blank overlay command]); ` +
+		`timezone: unknown timezone "Nowhere"`
+	if err == nil || err.Error() != want {
+		t.Fatalf("Validate = %v\nwant %s", err, want)
+	}
+
+	fe := FieldError{Field: "schedule.windows[1]", Message: "has no days"}
+	if got := fe.Error(); got != "schedule.windows[1]: has no days" {
+		t.Fatalf("FieldError = %q", got)
+	}
+}
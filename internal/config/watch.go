@@ -0,0 +1,105 @@
+package config
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Watch sends on the returned channel whenever the file at path is saved,
+// until ctx is done. It watches the directory, as saving replaces the
+// file rather than writing to it. Saves made before the last one was
+// received are reported once.
+func Watch(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("watching %s: %w", path, err)
+	}
+	dir, name := filepath.Split(path)
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("watching %s: %w", path, err)
+	}
+
+	// Non-blocking, so that closing it ends a read under way
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	saved := make(chan struct{}, 1)
+	go func() {
+		defer close(saved)
+
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				// struct inotify_event: wd, mask, cookie, len, then name
+				length := int(binary.NativeEndian.Uint32(buf[off+12:]))
+				start := off + syscall.SizeofInotifyEvent
+				off = start + length
+				if strings.TrimRight(string(buf[start:off]), "\x00") != name {
+					continue
+				}
+				select {
+				case saved <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return saved, nil
+}
+
+// Changes describes the settings that differ in to, such as
+// "work_duration 50m → 45m", in the order of Config's fields
+func (c *Config) Changes(to *Config) []string {
+	var changes []string
+	from, next := reflect.ValueOf(c).Elem(), reflect.ValueOf(to).Elem()
+	t := from.Type()
+	for i := range t.NumField() {
+		a, b := from.Field(i), next.Field(i)
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "version" || reflect.DeepEqual(a.Interface(), b.Interface()) {
+			continue
+		}
+
+		switch a.Kind() {
+		case reflect.Slice:
+			// A missing list and an empty one are the same
+			if a.Len() == 0 && b.Len() == 0 {
+				continue
+			}
+			changes = append(changes, name+" changed")
+		case reflect.Struct:
+			changes = append(changes, name+" changed")
+		default:
+			changes = append(changes, fmt.Sprintf("%s %s → %s", name, formatSetting(a.Interface()), formatSetting(b.Interface())))
+		}
+	}
+	return changes
+}
+
+// formatSetting formats the value of a setting as config.json has it
+func formatSetting(v any) string {
+	switch v := v.(type) {
+	case time.Duration:
+		return formatDuration(v)
+	case string:
+		if v == "" {
+			return `""`
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// expectSaved fails the test unless saved reports a save, or, if want is
+// false, stays quiet for a while
+func expectSaved(t *testing.T, saved <-chan struct{}, want bool) {
+	t.Helper()
+	wait := 5 * time.Second
+	if !want {
+		wait = 100 * time.Millisecond
+	}
+	select {
+	case _, ok := <-saved:
+		if !ok {
+			t.Fatal("watch ended")
+		}
+		if !want {
+			t.Fatal("save reported, want none")
+		}
+	case <-time.After(wait):
+		if want {
+			t.Fatal("save not reported")
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	saved, err := Watch(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saved by replacing the file, or written in place by an editor
+	if err := writeFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectSaved(t, saved, true)
+	if err := os.WriteFile(path, []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectSaved(t, saved, true)
+
+	// Other files in the directory are ignored
+	if err := writeFile(goodPath(path), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectSaved(t, saved, false)
+
+	// Saves made while the receiver was busy are reported once
+	for range 3 {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	expectSaved(t, saved, true)
+	expectSaved(t, saved, false)
+
+	cancel()
+	select {
+	case _, ok := <-saved:
+		if ok {
+			t.Fatal("save reported after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestWatchMissingDirectory(t *testing.T) {
+	_, err := Watch(context.Background(), filepath.Join(t.TempDir(), "missing", "config.json"))
+	if err == nil {
+		t.Fatal("watching a missing directory succeeded")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"none", func(c *Config) {}, nil},
+		{"version only", func(c *Config) { c.Version = 0 }, nil},
+		{"empty list for none", func(c *Config) { c.Calendars = []string{} }, nil},
+		{"duration", func(c *Config) { c.WorkDuration = 45 * time.Minute }, []string{"work_duration 50m → 45m"}},
+		{"bool", func(c *Config) { c.AlwaysOn = true }, []string{"always_on false → true"}},
+		{"set string", func(c *Config) { c.Timezone = "UTC" }, []string{`timezone "" → UTC`}},
+		{"list", func(c *Config) { c.Calendars = []string{"/home/me/work.ics"} }, []string{"calendars changed"}},
+		{"struct", func(c *Config) { c.Schedule.Exceptions = []string{"2025-12-25"} }, []string{"schedule changed"}},
+		{
+			"in field order",
+			func(c *Config) { c.EndAction, c.BreakDuration = ActionLock, 5*time.Minute },
+			[]string{"break_duration 10m → 5m", "end_action suspend → lock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := DefaultConfig()
+			tt.change(to)
+			if got := DefaultConfig().Changes(to); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// Client talks to a Server over its socket
+type Client struct {
+	path string
+}
+
+// NewClient returns a client for the socket at path. No connection is
+// made until a request is sent.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Start begins a work session, lasting work if it is non-zero or the
+// configured work duration otherwise
+func (c *Client) Start(work time.Duration) (timer.Status, error) {
+	return c.call(Request{Command: CmdStart, Work: work})
+}
+
+// Stop stops the timer
+func (c *Client) Stop() (timer.Status, error) {
+	return c.call(Request{Command: CmdStop})
+}
+
+// Extend extends the current work session. It fails if the timer refuses.
+func (c *Client) Extend() (timer.Status, error) {
+	return c.call(Request{Command: CmdExtend})
+}
+
+// Pause pauses the current session
+func (c *Client) Pause() (timer.Status, error) {
+	return c.call(Request{Command: CmdPause})
+}
+
+// Resume resumes a paused session
+func (c *Client) Resume() (timer.Status, error) {
+	return c.call(Request{Command: CmdResume})
+}
+
+// Status returns the timer's current status
+func (c *Client) Status() (timer.Status, error) {
+	return c.call(Request{Command: CmdStatus})
+}
+
+// dial connects to the server, unless its socket is somewhere another
+// user could have put it
+func (c *Client) dial() (net.Conn, error) {
+	if err := privateDir(filepath.Dir(c.path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return net.Dial("unix", c.path)
+}
+
+// Do sends req on a fresh connection and returns the server's response
+func (c *Client) Do(req Request) (Response, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+
+	var resp Response
+	err = json.NewDecoder(conn).Decode(&resp)
+	return resp, err
+}
+
+// Subscribe returns the timer's current status and streams its events
+// until stop is called or the server goes away, at which point the
+// channel is closed
+func (c *Client) Subscribe() (timer.Status, <-chan timer.Event, func(), error) {
+	conn, err := c.dial()
+	if err != nil {
+		return timer.Status{}, nil, nil, err
+	}
+	if err := json.NewEncoder(conn).Encode(Request{Command: CmdSubscribe}); err != nil {
+		conn.Close()
+		return timer.Status{}, nil, nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	var first Response
+	if !scanner.Scan() {
+		conn.Close()
+		if err := scanner.Err(); err != nil {
+			return timer.Status{}, nil, nil, err
+		}
+		return timer.Status{}, nil, nil, errors.New("server closed the subscription")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &first); err != nil || first.Status == nil {
+		conn.Close()
+		return timer.Status{}, nil, nil, fmt.Errorf("invalid subscription response: %s", scanner.Bytes())
+	}
+
+	ch := make(chan timer.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(ch)
+
+		for scanner.Scan() {
+			var resp Response
+			if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil || resp.Event == nil {
+				continue
+			}
+			select {
+			case ch <- *resp.Event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			conn.Close()
+		})
+	}
+	return *first.Status, ch, stop, nil
+}
+
+// call sends a command and turns a refusal into an error
+func (c *Client) call(req Request) (timer.Status, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return timer.Status{}, err
+	}
+
+	var status timer.Status
+	if resp.Status != nil {
+		status = *resp.Status
+	}
+	if !resp.OK {
+		return status, errors.New(resp.Error)
+	}
+	return status, nil
+}
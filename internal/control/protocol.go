@@ -0,0 +1,36 @@
+package control
+
+import (
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// Commands understood by the server
+const (
+	CmdStart     = "start"
+	CmdStop      = "stop"
+	CmdExtend    = "extend"
+	CmdPause     = "pause"
+	CmdResume    = "resume"
+	CmdStatus    = "status"
+	CmdSubscribe = "subscribe"
+)
+
+// Request is one line sent by a client. A connection may carry any number
+// of requests, each answered by one Response, until it subscribes; from
+// then on the server streams a Response carrying every timer Event.
+type Request struct {
+	Command string        `json:"command"`
+	Work    time.Duration `json:"work,omitempty"` // One-off work length for start
+}
+
+// Response answers a Request. Status reflects the timer after the command
+// was applied. On a subscription, Event is set on every response after
+// the first.
+type Response struct {
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Status *timer.Status `json:"status,omitempty"`
+	Event  *timer.Event  `json:"event,omitempty"`
+}
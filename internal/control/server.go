@@ -0,0 +1,210 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// Server exposes a Timer on a Unix domain socket
+type Server struct {
+	timer    *timer.Timer
+	listener net.Listener
+
+	// done is closed by Close to end every subscription
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen creates the control socket at path for t. A socket left behind
+// by a process that is gone is replaced; one still in use is an error.
+// The directory holding it is created if missing, and must be private to
+// the current user.
+func Listen(path string, t *timer.Timer) (*Server, error) {
+	dir := filepath.Dir(path)
+	if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, err
+	}
+	if err := privateDir(dir); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("pomoduru is already running (%s)", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the owner may drive the timer
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &Server{
+		timer:    t,
+		listener: listener,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// privateDir checks that dir is a directory only the current user can
+// add files to. In a shared one such as /tmp, someone else could have
+// made it first, to stand in for the daemon or have it remove their
+// socket.
+func privateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	switch {
+	case !info.IsDir():
+		return fmt.Errorf("refusing to use %s for the socket: not a directory", dir)
+	case !ok || int(st.Uid) != os.Getuid():
+		return fmt.Errorf("refusing to use %s for the socket: owned by another user", dir)
+	case info.Mode().Perm()&0022 != 0:
+		return fmt.Errorf("refusing to use %s for the socket: writable by other users (%v)", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// Serve accepts connections until Close is called
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting connections, ends every subscription and removes
+// the socket
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return s.listener.Close()
+}
+
+// handle serves the requests of one connection
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+
+		if req.Command == CmdSubscribe {
+			s.stream(conn, encoder)
+			return
+		}
+
+		if err := encoder.Encode(s.apply(req)); err != nil {
+			return
+		}
+	}
+}
+
+// apply runs one command against the timer
+func (s *Server) apply(req Request) Response {
+	ok := true
+	switch req.Command {
+	case CmdStart:
+		if req.Work > 0 {
+			s.timer.StartFor(req.Work)
+		} else {
+			s.timer.Start()
+		}
+	case CmdStop:
+		s.timer.Stop()
+	case CmdExtend:
+		ok = s.timer.Extend()
+	case CmdPause:
+		ok = s.timer.Pause()
+	case CmdResume:
+		ok = s.timer.Resume()
+	case CmdStatus:
+	default:
+		return Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+	}
+
+	status := s.timer.Status()
+	resp := Response{OK: ok, Status: &status}
+	if !ok {
+		resp.Error = fmt.Sprintf("cannot %s while %s", req.Command, describe(status))
+	}
+	return resp
+}
+
+// describe explains the timer state in refusal messages
+func describe(status timer.Status) string {
+	switch {
+	case status.Paused:
+		return "paused"
+	case status.State == timer.StateWarning && status.ExtendUsed:
+		return "the extension is already used"
+	}
+	return status.State.String()
+}
+
+// stream sends the current status and then every timer event until the
+// client goes away or the server is closed
+func (s *Server) stream(conn net.Conn, encoder *json.Encoder) {
+	events, cancel := s.timer.Subscribe()
+	defer cancel()
+
+	// Notice the client hanging up even while nothing changes
+	gone := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(gone)
+				return
+			}
+		}
+	}()
+
+	status := s.timer.Status()
+	if err := encoder.Encode(Response{OK: true, Status: &status}); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case ev := <-events:
+			if err := encoder.Encode(Response{OK: true, Status: &ev.Status, Event: &ev}); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// newTestServer serves a timer on a fake clock on a socket in a temporary
+// directory, and returns a client for it
+func newTestServer(t *testing.T) (*Client, *Server, *timer.Timer, *timer.FakeClock) {
+	t.Helper()
+	clock := timer.NewFakeClock(time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC))
+	tm := timer.NewTimer(config.DefaultConfig(),
+		timer.WithClock(clock),
+		timer.WithActions(&timer.RecordingActions{}),
+		timer.WithResumeWatcher(&timer.FakeResumeWatcher{}),
+	)
+
+	path := filepath.Join(t.TempDir(), "pomoduru.sock")
+	srv, err := Listen(path, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve()
+	t.Cleanup(func() { srv.Close() })
+	return NewClient(path), srv, tm, clock
+}
+
+func TestCommands(t *testing.T) {
+	c, _, _, clock := newTestServer(t)
+
+	st, err := c.Status()
+	if err != nil || st.State != timer.StateIdle {
+		t.Fatalf("status = %v, %v, want idle", st.State, err)
+	}
+
+	st, err = c.Start(0)
+	if err != nil || st.State != timer.StateWorking || st.Remaining != 50*time.Minute {
+		t.Fatalf("start = %+v, %v, want 50m of work", st, err)
+	}
+
+	if _, err := c.Extend(); err == nil || !strings.Contains(err.Error(), "cannot extend while working") {
+		t.Fatalf("extend while working = %v, want a refusal", err)
+	}
+
+	clock.Advance(45 * time.Minute)
+	if st, err := c.Extend(); err != nil || st.State != timer.StateExtended {
+		t.Fatalf("extend during the warning = %v, %v", st.State, err)
+	}
+
+	st, err = c.Pause()
+	if err != nil || !st.Paused {
+		t.Fatalf("pause = %+v, %v", st, err)
+	}
+	if _, err := c.Pause(); err == nil || !strings.Contains(err.Error(), "while paused") {
+		t.Fatalf("second pause = %v, want a refusal", err)
+	}
+	if st, err := c.Resume(); err != nil || st.Paused {
+		t.Fatalf("resume = %+v, %v", st, err)
+	}
+
+	if st, err := c.Stop(); err != nil || st.State != timer.StateIdle {
+		t.Fatalf("stop = %v, %v, want idle", st.State, err)
+	}
+
+	st, err = c.Start(25 * time.Minute)
+	if err != nil || st.Total != 25*time.Minute {
+		t.Fatalf("start for 25m = %+v, %v", st, err)
+	}
+
+	resp, err := c.Do(Request{Command: "explode"})
+	if err != nil || resp.OK || !strings.Contains(resp.Error, "unknown command") {
+		t.Fatalf("unknown command = %+v, %v", resp, err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c, srv, tm, _ := newTestServer(t)
+
+	st, events, stop, err := c.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	if st.State != timer.StateIdle {
+		t.Fatalf("first status = %s, want idle", st.State)
+	}
+
+	tm.Start()
+	select {
+	case ev := <-events:
+		if ev.Kind != timer.EventStarted || ev.Status.State != timer.StateWorking {
+			t.Fatalf("event = %s (%s), want started", ev.Kind, ev.Status.State)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event after Start")
+	}
+
+	srv.Close()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("subscription still open after Close")
+		}
+	}
+}
+
+func TestListenMakesPrivateDir(t *testing.T) {
+	tm := timer.NewTimer(config.DefaultConfig(), timer.WithActions(&timer.RecordingActions{}))
+	dir := filepath.Join(t.TempDir(), "pomoduru-1000")
+	path := filepath.Join(dir, "pomoduru.sock")
+
+	srv, err := Listen(path, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve()
+	defer srv.Close()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Fatalf("socket directory has mode %v, want 0700", perm)
+	}
+	if _, err := NewClient(path).Status(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSharedSocketDirRefused(t *testing.T) {
+	tm := timer.NewTimer(config.DefaultConfig(), timer.WithActions(&timer.RecordingActions{}))
+	dir := filepath.Join(t.TempDir(), "shared")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Made before us, as anyone could in /tmp
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "pomoduru.sock")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Listen(path, tm); err == nil || !strings.Contains(err.Error(), "writable by other users") {
+		t.Fatalf("Listen = %v, want a refusal", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("file in the way removed: %v", err)
+	}
+	if _, err := NewClient(path).Status(); err == nil || !strings.Contains(err.Error(), "writable by other users") {
+		t.Fatalf("Status = %v, want a refusal", err)
+	}
+
+	// Nor is a link to somewhere else followed
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(t.TempDir(), link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(filepath.Join(link, "pomoduru.sock"), tm); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("Listen through a link = %v, want a refusal", err)
+	}
+}
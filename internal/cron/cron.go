@@ -0,0 +1,187 @@
+// Package cron parses standard 5-field cron expressions and computes
+// when they next fire.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/wallclock"
+)
+
+// Schedule is a parsed cron expression: minute, hour, day of month, month
+// and day of week
+type Schedule struct {
+	expr string
+
+	minute, hour, dom, month, dow uint64 // Bit n set when value n matches
+
+	// Standard cron fires when either day field matches if both are
+	// restricted, and when both do otherwise
+	domStar, dowStar bool
+}
+
+// field describes one of the five fields
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names for min, min+1, ...
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is Sunday too
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Parse parses an expression such as "0,30 9-17 * * mon-fri". Each field
+// is *, a value, a range a-b, or a comma-separated list of them, and may
+// be followed by a step /n. Months and weekdays may be given by their
+// three-letter English names.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day month weekday), got %d", expr, len(parts))
+	}
+
+	s := &Schedule{expr: strings.Join(parts, " ")}
+	sets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, part := range parts {
+		bits, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		*sets[i] = bits
+	}
+
+	// Fold Sunday as 7 onto 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = strings.HasPrefix(parts[2], "*")
+	s.dowStar = strings.HasPrefix(parts[4], "*")
+	return s, nil
+}
+
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(spec, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepStr, f.name)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch from, to, isRange := strings.Cut(rng, "-"); {
+		case rng == "*":
+			lo, hi = f.min, f.max
+		case isRange:
+			var err error
+			if lo, err = parseValue(from, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(to, f); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in %s", rng, f.name)
+			}
+		default:
+			v, err := parseValue(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			// A single value with a step runs to the end, as in 5/15
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	lower := strings.ToLower(s)
+	for i, name := range f.names {
+		if lower == name {
+			return f.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q (want %d-%d)", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Match reports whether the schedule fires in the minute containing t
+func (s *Schedule) Match(t time.Time) bool {
+	return s.minute&(1<<t.Minute()) != 0 &&
+		s.hour&(1<<t.Hour()) != 0 &&
+		s.month&(1<<int(t.Month())) != 0 &&
+		s.dayMatches(t)
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t at which the schedule fires, in t's
+// location, or the zero time if it never does (such as on February 30).
+// Fields are matched against wall-clock time: a time skipped when the
+// clocks go forward doesn't fire, and one repeated when they fall back
+// fires only the first time, even if t is between the two.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Every schedule that can fire at all does so within two leap years,
+	// allowing for 2100 not being one
+	limit := t.AddDate(8, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case s.month&(1<<int(month)) == 0:
+			t = wallclock.Date(year, month+1, 1, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = wallclock.Date(year, month, day+1, 0, 0, loc)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = wallclock.Date(year, month, day, t.Hour()+1, 0, loc)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		case !wallclock.Date(year, month, day, t.Hour(), t.Minute(), loc).Equal(t):
+			// The second time round a repeated hour
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,277 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// set returns the bits of a field holding values
+func set(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << v
+	}
+	return bits
+}
+
+// span returns the bits of a field holding lo to hi
+func span(lo, hi int) uint64 {
+	var bits uint64
+	for v := lo; v <= hi; v++ {
+		bits |= 1 << v
+	}
+	return bits
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Schedule
+	}{
+		{
+			"0,30 9-17 * * mon-fri",
+			Schedule{minute: set(0, 30), hour: span(9, 17), dom: span(1, 31), month: span(1, 12), dow: span(1, 5), domStar: true},
+		},
+		{
+			"5/15 */6 1,15 * *",
+			Schedule{minute: set(5, 20, 35, 50), hour: set(0, 6, 12, 18), dom: set(1, 15), month: span(1, 12), dow: span(0, 6), dowStar: true},
+		},
+		{
+			"0 12 * JAN-Mar/2 sun",
+			Schedule{minute: set(0), hour: set(12), dom: span(1, 31), month: set(1, 3), dow: set(0), domStar: true},
+		},
+		{
+			// Sunday is 0 or 7
+			"0 0 * * 7",
+			Schedule{minute: set(0), hour: set(0), dom: span(1, 31), month: span(1, 12), dow: set(0), domStar: true},
+		},
+		{
+			"0 0 * * 5-7",
+			Schedule{minute: set(0), hour: set(0), dom: span(1, 31), month: span(1, 12), dow: set(0, 5, 6), domStar: true},
+		},
+		{
+			"  0  9   13 * fri ",
+			Schedule{minute: set(0), hour: set(9), dom: set(13), month: span(1, 12), dow: set(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.expr = strings.Join(strings.Fields(tt.expr), " ")
+			if *got != tt.want {
+				t.Fatalf("Parse = %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"0 9 * *", "want 5 fields"},
+		{"0 9 * * * *", "want 5 fields"},
+		{"60 * * * *", `invalid minute "60" (want 0-59)`},
+		{"* 24 * * *", `invalid hour "24"`},
+		{"* * 0 * *", `invalid day of month "0"`},
+		{"* * * 13 *", `invalid month "13"`},
+		{"* * * * 8", `invalid day of week "8"`},
+		{"* * * * monday", `invalid day of week "monday"`},
+		{"17-9 * * * *", `invalid range "17-9" in minute`},
+		{"*/0 * * * *", `invalid step "0" in minute`},
+		{"*/x * * * *", `invalid step "x" in minute`},
+		{"1,,2 * * * *", `invalid minute ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		loc   *time.Location
+		since time.Time // In UTC
+		want  time.Time // In UTC, zero for never
+	}{
+		{
+			name:  "later today",
+			expr:  "0,30 9-17 * * mon-fri",
+			loc:   time.UTC,
+			since: utc("2025-06-02 09:10"),
+			want:  utc("2025-06-02 09:30"),
+		},
+		{
+			name:  "strictly after since",
+			expr:  "30 9 * * *",
+			loc:   time.UTC,
+			since: utc("2025-06-02 09:30"),
+			want:  utc("2025-06-03 09:30"),
+		},
+		{
+			name:  "over the weekend",
+			expr:  "0,30 9-17 * * mon-fri",
+			loc:   time.UTC,
+			since: utc("2025-06-06 17:30"), // Friday
+			want:  utc("2025-06-09 09:00"),
+		},
+		{
+			name:  "either day field matches when both are restricted",
+			expr:  "0 9 13 * fri",
+			loc:   time.UTC,
+			since: utc("2025-06-01 00:00"),
+			want:  utc("2025-06-06 09:00"), // Friday, before the 13th
+		},
+		{
+			name:  "day of month alone",
+			expr:  "0 9 13 * *",
+			loc:   time.UTC,
+			since: utc("2025-06-01 00:00"),
+			want:  utc("2025-06-13 09:00"),
+		},
+		{
+			name:  "Sunday as 7",
+			expr:  "0 9 * * 7",
+			loc:   time.UTC,
+			since: utc("2025-06-02 00:00"),
+			want:  utc("2025-06-08 09:00"),
+		},
+		{
+			name:  "into the next year",
+			expr:  "0 0 1 jan *",
+			loc:   time.UTC,
+			since: utc("2025-06-02 00:00"),
+			want:  utc("2026-01-01 00:00"),
+		},
+		{
+			name:  "leap day",
+			expr:  "0 0 29 2 *",
+			loc:   time.UTC,
+			since: utc("2025-03-01 00:00"),
+			want:  utc("2028-02-29 00:00"),
+		},
+		{
+			name:  "never",
+			expr:  "0 0 30 2 *",
+			loc:   time.UTC,
+			since: utc("2025-01-01 00:00"),
+		},
+		{
+			// 2025-03-09 02:00 EST jumps to 03:00 EDT
+			name:  "spring forward skips a time that doesn't exist",
+			expr:  "30 2 * * *",
+			loc:   ny,
+			since: utc("2025-03-09 05:00"), // 00:00 EST
+			want:  utc("2025-03-10 06:30"), // 02:30 EDT the next day
+		},
+		{
+			name:  "spring forward, the hour after the jump",
+			expr:  "0 3 * * *",
+			loc:   ny,
+			since: utc("2025-03-09 06:59"), // 01:59 EST
+			want:  utc("2025-03-09 07:00"), // 03:00 EDT
+		},
+		{
+			name:  "spring forward, every half hour",
+			expr:  "*/30 * * * *",
+			loc:   ny,
+			since: utc("2025-03-09 06:45"), // 01:45 EST
+			want:  utc("2025-03-09 07:00"), // 03:00 EDT
+		},
+		{
+			// 2025-11-02 02:00 EDT falls back to 01:00 EST
+			name:  "fall back fires the first time",
+			expr:  "30 1 * * *",
+			loc:   ny,
+			since: utc("2025-11-02 04:00"), // 00:00 EDT
+			want:  utc("2025-11-02 05:30"), // 01:30 EDT
+		},
+		{
+			name:  "fall back, after the first time",
+			expr:  "30 1 * * *",
+			loc:   ny,
+			since: utc("2025-11-02 05:45"), // The first 01:45, EDT
+			want:  utc("2025-11-03 06:30"), // 01:30 EST the next day
+		},
+		{
+			name:  "fall back, since inside the repeated hour",
+			expr:  "30 1 * * *",
+			loc:   ny,
+			since: utc("2025-11-02 06:15"), // The second 01:15, EST
+			want:  utc("2025-11-03 06:30"),
+		},
+		{
+			name:  "fall back, hourly skips the repeated hour",
+			expr:  "0 * * * *",
+			loc:   ny,
+			since: utc("2025-11-02 05:00"), // The first 01:00, EDT
+			want:  utc("2025-11-02 07:00"), // 02:00 EST
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Next(tt.since.In(tt.loc))
+			if !got.Equal(tt.want) {
+				t.Fatalf("Next = %s, want %s", got.UTC(), tt.want)
+			}
+			if !got.IsZero() && got.Location() != tt.loc {
+				t.Fatalf("Next in %s, want %s", got.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+func TestNextRepeatedHourOnce(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Parse("*/15 1 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Following Next through the night fires each wall time once
+	seen := make(map[string]bool)
+	end := time.Date(2025, 11, 2, 12, 0, 0, 0, ny)
+	for at := s.Next(time.Date(2025, 11, 2, 0, 0, 0, 0, ny)); at.Before(end); at = s.Next(at) {
+		clock := at.Format("15:04")
+		if seen[clock] {
+			t.Fatalf("%s fired twice", clock)
+		}
+		seen[clock] = true
+	}
+	if len(seen) != 4 {
+		t.Fatalf("fired at %v, want 01:00, 01:15, 01:30 and 01:45", seen)
+	}
+}
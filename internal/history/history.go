@@ -0,0 +1,129 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session kinds
+const (
+	KindWork      = "work"
+	KindBreak     = "break"
+	KindLongBreak = "long_break"
+)
+
+// How a session ended
+const (
+	OutcomeCompleted = "completed" // Ran to its deadline
+	OutcomeStopped   = "stopped"   // Stopped or restarted before its deadline
+	OutcomeSkipped   = "skipped"   // Break slept through while suspended
+)
+
+// Entry is one finished work or break session
+type Entry struct {
+	Kind     string        `json:"kind"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Planned  time.Duration `json:"planned"`          // Length it was scheduled for, including any extension
+	Actual   time.Duration `json:"actual"`           // Time actually spent, not counting pauses
+	Paused   time.Duration `json:"paused,omitempty"` // Time spent paused
+	Extended bool          `json:"extended,omitempty"`
+	Cycle    int           `json:"cycle,omitempty"` // Position within the set of long-break cycles
+	Outcome  string        `json:"outcome"`
+	Action   string        `json:"action,omitempty"` // End action run when a work session completed
+}
+
+// Filter selects entries to read. Zero fields match everything.
+type Filter struct {
+	Since time.Time // Entries starting at or after Since
+	Until time.Time // Entries starting before Until
+	Kind  string
+}
+
+// Match reports whether e passes the filter
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Start.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Start.Before(f.Until) {
+		return false
+	}
+	return f.Kind == "" || e.Kind == f.Kind
+}
+
+// Store is an append-only JSON Lines history file
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Open returns the store kept at path. The file is created on the first
+// Append.
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the file backing the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds e to the end of the history
+func (s *Store) Append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	// One write per entry so concurrent appenders don't interleave
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read returns the entries matching f, oldest first. A missing file is an
+// empty history, and lines that can't be parsed (such as one cut short by
+// a crash) are skipped.
+func (s *Store) Read(f Filter) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if f.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,133 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func entry(kind string, start time.Time) Entry {
+	return Entry{
+		Kind:    kind,
+		Start:   start,
+		End:     start.Add(50 * time.Minute),
+		Planned: 50 * time.Minute,
+		Actual:  45 * time.Minute,
+		Paused:  5 * time.Minute,
+		Cycle:   2,
+		Outcome: OutcomeCompleted,
+		Action:  "suspend",
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	// The directory is created on the first Append
+	s := Open(filepath.Join(t.TempDir(), "pomoduru", "history.jsonl"))
+	if entries, err := s.Read(Filter{}); err != nil || entries != nil {
+		t.Fatalf("missing history = %v, %v, want empty", entries, err)
+	}
+
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	want := []Entry{
+		entry(KindWork, day.Add(9*time.Hour)),
+		entry(KindBreak, day.Add(10*time.Hour)),
+		entry(KindWork, day.Add(34*time.Hour)),
+		entry(KindLongBreak, day.Add(35*time.Hour)),
+	}
+	for _, e := range want {
+		if err := s.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("read back %+v\nwant %+v", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []Entry
+	}{
+		{"kind", Filter{Kind: KindWork}, []Entry{want[0], want[2]}},
+		{"since, inclusive", Filter{Since: want[1].Start}, want[1:]},
+		{"until, exclusive", Filter{Until: want[2].Start}, want[:2]},
+		{"one day of work", Filter{Since: day, Until: day.AddDate(0, 0, 1), Kind: KindWork}, want[:1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Read(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("read %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestReadSkipsBrokenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s := Open(path)
+	first := entry(KindWork, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+	if err := s.Append(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A line cut short by a crash, then more history
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"kind":"work","start":"2025-06-0` + "\n")
+	file.Close()
+	second := entry(KindBreak, first.End)
+	if err := s.Append(second); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []Entry{first, second}) {
+		t.Fatalf("read %+v, want the two whole entries", got)
+	}
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	// Separate stores, as separate processes would have
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := Open(path)
+			for j := range 50 {
+				if err := s.Append(entry(KindWork, start.Add(time.Duration(i*50+j)*time.Minute))); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := Open(path).Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 200 {
+		t.Fatalf("read %d entries, want 200", len(got))
+	}
+}
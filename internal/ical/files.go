@@ -0,0 +1,74 @@
+package ical
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Files is a set of calendar files, read again whenever they change
+type Files struct {
+	paths []string
+
+	mu     sync.Mutex
+	loaded map[string]loadedFile
+}
+
+type loadedFile struct {
+	modTime time.Time
+	size    int64
+	cal     *Calendar
+	err     error
+}
+
+// OpenFiles returns the calendars in paths. Nothing is read until the
+// first call to Busy.
+func OpenFiles(paths ...string) *Files {
+	return &Files{
+		paths:  paths,
+		loaded: make(map[string]loadedFile),
+	}
+}
+
+// Busy returns the busy spans of every calendar overlapping from to to,
+// sorted by start. Calendars that can't be read are left out and reported
+// in the error, which doesn't stop the others from counting.
+func (f *Files) Busy(from, to time.Time) ([]Span, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var spans []Span
+	var errs []error
+	for _, path := range f.paths {
+		cal, err := f.load(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		spans = append(spans, cal.Busy(from, to)...)
+	}
+
+	slices.SortFunc(spans, func(a, b Span) int {
+		return a.Start.Compare(b.Start)
+	})
+	return spans, errors.Join(errs...)
+}
+
+// load returns the calendar at path, reading it again if it changed
+// since the last time. Must be called with mu held.
+func (f *Files) load(path string) (*Calendar, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l, ok := f.loaded[path]
+	if !ok || !l.modTime.Equal(info.ModTime()) || l.size != info.Size() {
+		l = loadedFile{modTime: info.ModTime(), size: info.Size()}
+		l.cal, l.err = Load(path)
+		f.loaded[path] = l
+	}
+	return l.cal, l.err
+}
@@ -0,0 +1,349 @@
+// Package ical reads busy times from iCalendar (.ics) files, expanding
+// recurring events.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one VEVENT
+type Event struct {
+	UID         string
+	Summary     string
+	Start       time.Time
+	End         time.Time
+	Duration    time.Duration // Length given by DURATION, for an event without DTEND
+	AllDay      bool
+	Transparent bool // Shown as free (TRANSP:TRANSPARENT)
+	Cancelled   bool
+	Rule        *Rule       // How the event recurs, if it does
+	ExDates     []time.Time // Occurrences removed from the rule
+
+	// RecurrenceID is set on an event replacing the occurrence of the
+	// recurring event with the same UID that starts at this time
+	RecurrenceID time.Time
+}
+
+// Calendar is the events of one .ics file
+type Calendar struct {
+	Events []Event
+}
+
+// Span is a busy period, such as one occurrence of a meeting
+type Span struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Load reads the calendar in the file at path
+func Load(path string) (*Calendar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cal, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// Parse reads a calendar. Events that can't be understood, such as ones
+// without a start, are skipped rather than failing the whole calendar.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	found := false
+	var ev *Event
+	var bad bool
+	depth := 0 // Components nested inside the current event, like VALARM
+
+	for _, line := range lines {
+		name, params, value, ok := splitLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			found = true
+		case name == "BEGIN" && value == "VEVENT" && ev == nil:
+			ev, bad, depth = &Event{}, false, 0
+		case ev == nil:
+			// Outside any event
+		case name == "BEGIN":
+			depth++
+		case name == "END" && depth > 0:
+			depth--
+		case name == "END" && value == "VEVENT":
+			if !bad && !ev.Start.IsZero() {
+				finish(ev)
+				cal.Events = append(cal.Events, *ev)
+			}
+			ev = nil
+		case depth > 0:
+			// Properties of a nested component
+		default:
+			if err := setProperty(ev, name, params, value); err != nil {
+				bad = true
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("not an iCalendar file")
+	}
+	return cal, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitLine splits a content line such as
+// DTSTART;TZID=Europe/Berlin:20250602T090000 into its name, parameters
+// and value
+func splitLine(line string) (name string, params map[string]string, value string, ok bool) {
+	// The value starts at the first colon outside quoted parameter values
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+func setProperty(ev *Event, name string, params map[string]string, value string) error {
+	var err error
+	switch name {
+	case "UID":
+		ev.UID = value
+	case "SUMMARY":
+		ev.Summary = unescape(value)
+	case "DTSTART":
+		ev.Start, ev.AllDay, err = parseTime(value, params)
+	case "DTEND":
+		ev.End, _, err = parseTime(value, params)
+	case "DURATION":
+		// Applied by finish, as it may come before DTSTART
+		ev.Duration, err = parseDuration(value)
+	case "TRANSP":
+		ev.Transparent = strings.EqualFold(value, "TRANSPARENT")
+	case "STATUS":
+		ev.Cancelled = strings.EqualFold(value, "CANCELLED")
+	case "RRULE":
+		ev.Rule, err = ParseRule(value)
+	case "EXDATE":
+		for _, v := range strings.Split(value, ",") {
+			t, _, err := parseTime(v, params)
+			if err != nil {
+				return err
+			}
+			ev.ExDates = append(ev.ExDates, t)
+		}
+	case "RECURRENCE-ID":
+		ev.RecurrenceID, _, err = parseTime(value, params)
+	}
+	return err
+}
+
+// finish fills in an end the event didn't give: from its DURATION if it
+// has one, or else a day for all-day events and none for the rest
+func finish(ev *Event) {
+	if !ev.End.IsZero() {
+		return
+	}
+	switch {
+	case ev.AllDay && ev.Duration%(24*time.Hour) == 0 && ev.Duration > 0:
+		// Whole days, whatever the clocks do
+		ev.End = ev.Start.AddDate(0, 0, int(ev.Duration/(24*time.Hour)))
+	case ev.Duration != 0:
+		ev.End = ev.Start.Add(ev.Duration)
+	case ev.AllDay:
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	default:
+		ev.End = ev.Start
+	}
+}
+
+// parseTime parses a DATE or DATE-TIME value. Times in UTC end in Z,
+// others are in the TZID parameter's zone or, without one, local time.
+// Zones Go doesn't know, such as Windows names, are taken as local time.
+func parseTime(value string, params map[string]string) (t time.Time, allDay bool, err error) {
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, loc)
+		allDay = true
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	if err != nil {
+		return t, false, fmt.Errorf("invalid time %q", value)
+	}
+	return t, allDay, nil
+}
+
+// parseDuration parses a DURATION value such as PT1H30M, P1D or P2W
+func parseDuration(value string) (time.Duration, error) {
+	s := value
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	units := map[byte]time.Duration{
+		'W': 7 * 24 * time.Hour,
+		'D': 24 * time.Hour,
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+	}
+	var d time.Duration
+	n := ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 'T':
+		case c >= '0' && c <= '9':
+			n += string(c)
+		default:
+			v, err := strconv.Atoi(n)
+			unit, ok := units[c]
+			if err != nil || !ok {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			d += time.Duration(v) * unit
+			n = ""
+		}
+	}
+	if n != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * d, nil
+}
+
+// unescape undoes TEXT escaping
+func unescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// Busy returns the busy spans overlapping from to to, sorted by start.
+// All-day events, and events that are cancelled or marked as free, don't
+// count as busy.
+func (c *Calendar) Busy(from, to time.Time) []Span {
+	// Occurrences replaced by another event
+	replaced := make(map[string][]time.Time)
+	for _, ev := range c.Events {
+		if !ev.RecurrenceID.IsZero() {
+			replaced[ev.UID] = append(replaced[ev.UID], ev.RecurrenceID)
+		}
+	}
+
+	var spans []Span
+	for _, ev := range c.Events {
+		if ev.AllDay || ev.Transparent || ev.Cancelled {
+			continue
+		}
+
+		skip := append(slices.Clone(ev.ExDates), replaced[ev.UID]...)
+		if !ev.RecurrenceID.IsZero() {
+			// An override is a single occurrence
+			skip = nil
+		}
+		ev.occurrences(from, to, skip, func(start time.Time) {
+			spans = append(spans, Span{
+				Summary: ev.Summary,
+				Start:   start,
+				End:     start.Add(ev.End.Sub(ev.Start)),
+			})
+		})
+	}
+
+	slices.SortFunc(spans, func(a, b Span) int {
+		return a.Start.Compare(b.Start)
+	})
+	return spans
+}
+
+// occurrences calls fn with the start of every occurrence overlapping
+// from to to, other than those starting at a time in skip
+func (ev Event) occurrences(from, to time.Time, skip []time.Time, fn func(time.Time)) {
+	length := ev.End.Sub(ev.Start)
+	emit := func(start time.Time) {
+		if start.Add(length).After(from) && start.Before(to) &&
+			!slices.ContainsFunc(skip, start.Equal) {
+			fn(start)
+		}
+	}
+
+	if ev.Rule == nil || !ev.RecurrenceID.IsZero() {
+		emit(ev.Start)
+		return
+	}
+	ev.Rule.each(ev.Start, func(start time.Time) bool {
+		if !start.Before(to) {
+			return false
+		}
+		emit(start)
+		return true
+	})
+}
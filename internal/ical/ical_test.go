@@ -0,0 +1,106 @@
+package ical
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// busy loads the fixture name from testdata and returns its busy spans
+// between from and to
+func busy(t *testing.T, name string, from, to time.Time) []Span {
+	t.Helper()
+	cal, err := Load("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cal.Busy(from, to)
+}
+
+// expectSpans fails the test unless got has the summaries and starts of
+// want, and each lasts length
+func expectSpans(t *testing.T, got []Span, length time.Duration, want ...Span) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d spans, want %d: %v", len(got), len(want), got)
+	}
+	for i, span := range got {
+		if span.Summary != want[i].Summary || !span.Start.Equal(want[i].Start) || span.End.Sub(span.Start) != length {
+			t.Errorf("span %d = %q %s - %s, want %q at %s for %s",
+				i, span.Summary, span.Start.UTC(), span.End.UTC(), want[i].Summary, want[i].Start.UTC(), length)
+		}
+	}
+}
+
+func utc(day, hour, min int, month time.Month) time.Time {
+	return time.Date(2025, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestWeeklyWithExDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day int) Span {
+		return Span{Summary: "Standup", Start: time.Date(2025, time.June, day, 9, 30, 0, 0, berlin)}
+	}
+
+	// Mondays and Wednesdays from June 2nd, four times, the 4th removed
+	// but still counted
+	spans := busy(t, "weekly.ics", utc(1, 0, 0, time.June), utc(1, 0, 0, time.July))
+	expectSpans(t, spans, 15*time.Minute, at(2), at(9), at(11))
+
+	// 09:30 in Berlin is 07:30 UTC in summer
+	if want := utc(2, 7, 30, time.June); !spans[0].Start.Equal(want) {
+		t.Errorf("first standup at %s, want %s", spans[0].Start.UTC(), want)
+	}
+}
+
+func TestMonthlyOnThe31st(t *testing.T) {
+	at := func(month time.Month) Span {
+		return Span{Summary: "Month end", Start: utc(31, 14, 0, month)}
+	}
+
+	// Months without a 31st are skipped, and don't count
+	spans := busy(t, "monthly.ics", utc(1, 0, 0, time.January), utc(31, 0, 0, time.December))
+	expectSpans(t, spans, time.Hour, at(time.January), at(time.March), at(time.May), at(time.July))
+}
+
+func TestRecurrenceOverride(t *testing.T) {
+	spans := busy(t, "override.ics", utc(1, 0, 0, time.June), utc(8, 0, 0, time.June))
+	expectSpans(t, spans[:1], 30*time.Minute, Span{Summary: "Sync", Start: utc(2, 10, 0, time.June)})
+	expectSpans(t, spans[1:2], time.Hour, Span{Summary: "Sync (moved)", Start: utc(3, 15, 0, time.June)})
+	expectSpans(t, spans[2:], 30*time.Minute, Span{Summary: "Sync", Start: utc(4, 10, 0, time.June)})
+
+	// Only the moved occurrence is busy on the 3rd
+	spans = busy(t, "override.ics", utc(3, 0, 0, time.June), utc(4, 0, 0, time.June))
+	expectSpans(t, spans, time.Hour, Span{Summary: "Sync (moved)", Start: utc(3, 15, 0, time.June)})
+}
+
+func TestFreeEvents(t *testing.T) {
+	// The all-day, transparent and cancelled events don't count; the
+	// review gives its DURATION before its DTSTART
+	spans := busy(t, "free.ics", utc(2, 0, 0, time.June), utc(3, 0, 0, time.June))
+	expectSpans(t, spans, time.Hour, Span{Summary: "Review", Start: utc(2, 13, 0, time.June)})
+
+	cal, err := Load("testdata/free.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range cal.Events {
+		switch ev.Summary {
+		case "Holiday":
+			if !ev.AllDay || ev.End.Sub(ev.Start) != 24*time.Hour {
+				t.Errorf("holiday = all day %v, %s - %s, want one whole day", ev.AllDay, ev.Start, ev.End)
+			}
+		case "Focus time":
+			if !ev.Transparent {
+				t.Error("focus time not transparent")
+			}
+		case "Planning":
+			if !ev.Cancelled {
+				t.Error("planning not cancelled")
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is a recurrence rule (RRULE). It supports the DAILY, WEEKLY,
+// MONTHLY and YEARLY frequencies with INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY and BYMONTH, which covers what calendar applications write.
+type Rule struct {
+	Freq       string // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval   int
+	Count      int       // Total occurrences, counting the first (0 = unlimited)
+	Until      time.Time // Last possible occurrence (zero = unlimited)
+	ByDay      []WeekdayNum
+	ByMonthDay []int // Days of the month, negative counting from the end
+	ByMonth    []time.Month
+}
+
+// WeekdayNum is a BYDAY entry such as MO, 1MO (the first Monday) or -1FR
+// (the last Friday)
+type WeekdayNum struct {
+	N   int // Occurrence within the month, or 0 for every one
+	Day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// maxPeriods bounds the expansion of a rule, in case it never reaches
+// the end of the range asked for
+const maxPeriods = 100000
+
+// ParseRule parses an RRULE value such as FREQ=WEEKLY;BYDAY=MO,WE
+func ParseRule(value string) (*Rule, error) {
+	r := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(val)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("invalid INTERVAL %q", val)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var allDay bool
+			r.Until, allDay, err = parseTime(val, nil)
+			if allDay {
+				// The whole of the last day
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				var wd WeekdayNum
+				if wd, err = parseWeekdayNum(d); err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(val, ",") {
+				var n int
+				if n, err = strconv.Atoi(d); err != nil {
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, m := range strings.Split(val, ",") {
+				var n int
+				if n, err = strconv.Atoi(m); err != nil {
+					break
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(n))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %q: %v", value, err)
+		}
+	}
+
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported RRULE frequency %q", r.Freq)
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	n := 0
+	if num := s[:len(s)-2]; num != "" {
+		var err error
+		if n, err = strconv.Atoi(num); err != nil {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+	return WeekdayNum{N: n, Day: day}, nil
+}
+
+// each calls fn with the start of every occurrence of the rule for an
+// event first starting at dtstart, in order, until fn returns false or
+// the rule ends
+func (r *Rule) each(dtstart time.Time, fn func(time.Time) bool) {
+	n := 0
+	for period := range maxPeriods {
+		for _, t := range r.period(dtstart, period) {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+			n++
+			if r.Count > 0 && n > r.Count {
+				return
+			}
+			if !fn(t) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the candidate occurrences in the period'th interval
+// after dtstart, sorted. Each keeps dtstart's time of day.
+func (r *Rule) period(dtstart time.Time, period int) []time.Time {
+	year, month, day := dtstart.Date()
+	hour, min, sec := dtstart.Clock()
+	loc := dtstart.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, loc)
+	}
+	step := period * r.Interval
+
+	var dates []time.Time
+	switch r.Freq {
+	case "DAILY":
+		dates = []time.Time{at(year, month, day+step)}
+
+	case "WEEKLY":
+		// Weeks start on Monday
+		monday := day - (int(dtstart.Weekday())+6)%7 + 7*step
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []WeekdayNum{{Day: dtstart.Weekday()}}
+		}
+		for _, wd := range days {
+			dates = append(dates, at(year, month, monday+(int(wd.Day)+6)%7))
+		}
+
+	case "MONTHLY":
+		first := at(year, month+time.Month(step), 1)
+		dates = r.inMonth(first, day)
+
+	case "YEARLY":
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+		for _, m := range months {
+			dates = append(dates, r.inMonth(at(year+step, m, 1), day)...)
+		}
+	}
+
+	// BYMONTH and, outside the rules it expands, BYDAY limit the dates
+	dates = slices.DeleteFunc(dates, func(t time.Time) bool {
+		if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, t.Month()) {
+			return true
+		}
+		if r.Freq == "DAILY" && len(r.ByDay) > 0 {
+			return !slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool { return wd.Day == t.Weekday() })
+		}
+		return false
+	})
+
+	slices.SortFunc(dates, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(dates, time.Time.Equal)
+}
+
+// inMonth returns the dates in the month starting at first given by
+// BYMONTHDAY or BYDAY, or else day of that month if it has one
+func (r *Rule) inMonth(first time.Time, day int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	at := func(d int) time.Time {
+		return first.AddDate(0, 0, d-1)
+	}
+
+	var dates []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = last + d + 1
+			}
+			if d < 1 || d > last {
+				continue
+			}
+			t := at(d)
+			// With BYDAY as well, both have to match
+			if len(r.ByDay) == 0 || slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool { return wd.Day == t.Weekday() }) {
+				dates = append(dates, t)
+			}
+		}
+
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			// Every matching weekday of the month
+			var all []time.Time
+			for d := 1 + (int(wd.Day)-int(first.Weekday())+7)%7; d <= last; d += 7 {
+				all = append(all, at(d))
+			}
+			switch {
+			case wd.N == 0:
+				dates = append(dates, all...)
+			case wd.N > 0 && wd.N <= len(all):
+				dates = append(dates, all[wd.N-1])
+			case wd.N < 0 && -wd.N <= len(all):
+				dates = append(dates, all[len(all)+wd.N])
+			}
+		}
+
+	case day <= last:
+		dates = append(dates, at(day))
+	}
+	return dates
+}
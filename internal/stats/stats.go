@@ -0,0 +1,235 @@
+// Package stats reports on the recorded session history: focus time,
+// completed and aborted pomodoros, extensions and streaks per day, week
+// or month.
+package stats
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+const dateLayout = "2006-01-02"
+
+// summary aggregates the work sessions of one period
+type summary struct {
+	Period        string        `json:"period"`
+	FocusTime     time.Duration `json:"-"`
+	FocusSeconds  int64         `json:"focus_seconds"`
+	Sessions      int           `json:"sessions"`
+	Completed     int           `json:"completed"`
+	Aborted       int           `json:"aborted"`
+	Extended      int           `json:"extended"`
+	ExtensionRate float64       `json:"extension_rate"`
+}
+
+// report is everything the command prints
+type report struct {
+	Since         string    `json:"since,omitempty"`
+	Until         string    `json:"until,omitempty"`
+	By            string    `json:"by"`
+	Total         summary   `json:"total"`
+	Periods       []summary `json:"periods"`
+	LongestStreak int       `json:"longest_streak_days"`
+}
+
+// Run prints the report asked for by args, the command line flags of the
+// command name
+func Run(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	since := fs.String("since", "", "Only count sessions on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "Only count sessions on or before this date (YYYY-MM-DD)")
+	by := fs.String("by", "day", "Group totals by day, week or month")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	file := fs.String("file", config.HistoryPath(), "History file to read")
+	fs.Usage = func() { printUsage(name) }
+	fs.Parse(args)
+
+	var filter history.Filter
+	var err error
+	if *since != "" {
+		if filter.Since, err = time.ParseInLocation(dateLayout, *since, time.Local); err != nil {
+			return fmt.Errorf("invalid --since date %q: want YYYY-MM-DD", *since)
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = time.ParseInLocation(dateLayout, *until, time.Local); err != nil {
+			return fmt.Errorf("invalid --until date %q: want YYYY-MM-DD", *until)
+		}
+		// Include the whole of the last day
+		filter.Until = filter.Until.AddDate(0, 0, 1)
+	}
+
+	period, ok := periods[*by]
+	if !ok {
+		return fmt.Errorf("invalid --by %q: want day, week or month", *by)
+	}
+
+	filter.Kind = history.KindWork
+	entries, err := history.Open(*file).Read(filter)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	r := buildReport(entries, period)
+	r.Since, r.Until, r.By = *since, *until, *by
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		return nil
+	}
+	printReport(r)
+	return nil
+}
+
+func printUsage(name string) {
+	fmt.Println("Pomoduru Statistics")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  " + name + " [flags]")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println("  --since date    Only count sessions on or after this date (YYYY-MM-DD)")
+	fmt.Println("  --until date    Only count sessions on or before this date (YYYY-MM-DD)")
+	fmt.Println("  --by period     Group totals by day, week or month (default day)")
+	fmt.Println("  --json          Print the report as JSON")
+	fmt.Println("  --file path     History file to read (default " + config.HistoryPath() + ")")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  " + name + " --by week")
+	fmt.Println("  " + name + " --since 2025-01-01 --by month --json")
+}
+
+// periods maps --by values to a function labelling the period a time
+// falls in. Labels sort in chronological order.
+var periods = map[string]func(time.Time) string{
+	"day": func(t time.Time) string {
+		return t.Format(dateLayout)
+	},
+	"week": func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	"month": func(t time.Time) string {
+		return t.Format("2006-01")
+	},
+}
+
+// buildReport aggregates work sessions into per-period and overall totals
+func buildReport(entries []history.Entry, period func(time.Time) string) report {
+	r := report{Total: summary{Period: "total"}}
+	index := make(map[string]int)
+	days := make(map[string]bool)
+
+	for _, e := range entries {
+		start := e.Start.Local()
+		label := period(start)
+		i, ok := index[label]
+		if !ok {
+			i = len(r.Periods)
+			index[label] = i
+			r.Periods = append(r.Periods, summary{Period: label})
+		}
+
+		add(&r.Periods[i], e)
+		add(&r.Total, e)
+		if e.Outcome == history.OutcomeCompleted {
+			days[start.Format(dateLayout)] = true
+		}
+	}
+
+	for i := range r.Periods {
+		finish(&r.Periods[i])
+	}
+	// The history is in the order sessions closed, which a restored
+	// session can break
+	slices.SortFunc(r.Periods, func(a, b summary) int {
+		return strings.Compare(a.Period, b.Period)
+	})
+	finish(&r.Total)
+	r.LongestStreak = longestStreak(days)
+	return r
+}
+
+func add(s *summary, e history.Entry) {
+	s.FocusTime += e.Actual
+	s.Sessions++
+	switch e.Outcome {
+	case history.OutcomeCompleted:
+		s.Completed++
+	case history.OutcomeStopped:
+		s.Aborted++
+	}
+	if e.Extended {
+		s.Extended++
+	}
+}
+
+func finish(s *summary) {
+	s.FocusSeconds = int64(s.FocusTime.Seconds())
+	if s.Sessions > 0 {
+		s.ExtensionRate = float64(s.Extended) / float64(s.Sessions)
+	}
+}
+
+// longestStreak returns the most consecutive days with at least one
+// completed pomodoro
+func longestStreak(days map[string]bool) int {
+	longest := 0
+	for day := range days {
+		start, _ := time.ParseInLocation(dateLayout, day, time.Local)
+
+		// Only count from the first day of each run
+		if days[start.AddDate(0, 0, -1).Format(dateLayout)] {
+			continue
+		}
+
+		n := 0
+		for d := start; days[d.Format(dateLayout)]; d = d.AddDate(0, 0, 1) {
+			n++
+		}
+		longest = max(longest, n)
+	}
+	return longest
+}
+
+func printReport(r report) {
+	fmt.Println("🍅 Pomoduru Statistics")
+	fmt.Println("═══════════════════════════")
+
+	if len(r.Periods) == 0 {
+		fmt.Println("No work sessions recorded yet.")
+		return
+	}
+
+	fmt.Printf("%-12s %10s %10s %8s %10s\n", "Period", "Focus", "Completed", "Aborted", "Extended")
+	for _, s := range r.Periods {
+		printRow(s)
+	}
+	fmt.Println("───────────────────────────────────────────────────────")
+	printRow(r.Total)
+
+	fmt.Println()
+	fmt.Printf("Extension rate:  %.0f%%\n", r.Total.ExtensionRate*100)
+	fmt.Printf("Longest streak:  %d days\n", r.LongestStreak)
+}
+
+func printRow(s summary) {
+	fmt.Printf("%-12s %10s %10d %8d %10d\n", s.Period, formatFocus(s.FocusTime), s.Completed, s.Aborted, s.Extended)
+}
+
+func formatFocus(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+func TestBuildReportSortsPeriods(t *testing.T) {
+	at := func(day int) time.Time {
+		return time.Date(2025, 6, day, 10, 0, 0, 0, time.Local)
+	}
+	// A session restored on the 3rd closed, and was recorded, after the
+	// one of the 4th
+	var entries []history.Entry
+	for _, day := range []int{2, 4, 3} {
+		entries = append(entries, history.Entry{
+			Kind:    history.KindWork,
+			Start:   at(day),
+			End:     at(day).Add(50 * time.Minute),
+			Actual:  50 * time.Minute,
+			Outcome: history.OutcomeCompleted,
+		})
+	}
+
+	r := buildReport(entries, periods["day"])
+	var got []string
+	for _, p := range r.Periods {
+		got = append(got, p.Period)
+	}
+	want := []string{"2025-06-02", "2025-06-03", "2025-06-04"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("periods = %v, want %v", got, want)
+	}
+	if r.LongestStreak != 3 {
+		t.Fatalf("longest streak = %d, want 3", r.LongestStreak)
+	}
+}
@@ -0,0 +1,173 @@
+package timer
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// SystemActions performs the side effects the timer has on the machine.
+// ExecActions shells out to the desktop and systemd; RecordingActions
+// only remembers what it was asked to do.
+type SystemActions interface {
+	Notify(title, message string) error
+	Suspend(args ...string) error
+	Hibernate(args ...string) error
+	HybridSleep(args ...string) error
+	Lock(args ...string) error
+	Blank(args ...string) error
+	Run(argv ...string) error
+}
+
+// ExecActions returns SystemActions that run notify-send, systemctl,
+// loginctl and xset
+func ExecActions() SystemActions {
+	return execActions{}
+}
+
+type execActions struct{}
+
+func (execActions) Notify(title, message string) error {
+	return run("notify-send", title, message)
+}
+
+// Suspend, Hibernate and HybridSleep pass args on to systemctl
+func (execActions) Suspend(args ...string) error {
+	return run("systemctl", append([]string{"suspend", "-i"}, args...)...)
+}
+
+func (execActions) Hibernate(args ...string) error {
+	return run("systemctl", append([]string{"hibernate", "-i"}, args...)...)
+}
+
+func (execActions) HybridSleep(args ...string) error {
+	return run("systemctl", append([]string{"hybrid-sleep", "-i"}, args...)...)
+}
+
+// Lock locks the session named by args, or the caller's own session
+func (execActions) Lock(args ...string) error {
+	return run("loginctl", append([]string{"lock-session"}, args...)...)
+}
+
+// Blank turns the display off with xset, or by running args instead
+// when given (e.g. for Wayland compositors)
+func (execActions) Blank(args ...string) error {
+	if len(args) > 0 {
+		return run(args[0], args[1:]...)
+	}
+	return run("xset", "dpms", "force", "off")
+}
+
+func (execActions) Run(argv ...string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("no command to run")
+	}
+	return run(argv[0], argv[1:]...)
+}
+
+// run executes a command and folds its output into the returned error
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// ActionCall is a single call made to RecordingActions
+type ActionCall struct {
+	Name string
+	Args []string
+}
+
+// RecordingActions is a SystemActions that records every call instead of
+// touching the system. It is used by tests and by --dry-run.
+type RecordingActions struct {
+	mu    sync.Mutex
+	calls []ActionCall
+	fail  map[string]error
+}
+
+// Notify records a notification
+func (r *RecordingActions) Notify(title, message string) error {
+	return r.record("notify", title, message)
+}
+
+// Suspend records a suspend request
+func (r *RecordingActions) Suspend(args ...string) error {
+	return r.record("suspend", args...)
+}
+
+// Hibernate records a hibernate request
+func (r *RecordingActions) Hibernate(args ...string) error {
+	return r.record("hibernate", args...)
+}
+
+// HybridSleep records a hybrid-sleep request
+func (r *RecordingActions) HybridSleep(args ...string) error {
+	return r.record("hybrid-sleep", args...)
+}
+
+// Lock records a screen lock request
+func (r *RecordingActions) Lock(args ...string) error {
+	return r.record("lock", args...)
+}
+
+// Blank records a display blank request
+func (r *RecordingActions) Blank(args ...string) error {
+	return r.record("blank", args...)
+}
+
+// Run records a command that would have been run
+func (r *RecordingActions) Run(argv ...string) error {
+	return r.record("command", argv...)
+}
+
+// FailWith makes every later call to the named action return err.
+// A nil err clears it.
+func (r *RecordingActions) FailWith(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail == nil {
+		r.fail = make(map[string]error)
+	}
+	if err == nil {
+		delete(r.fail, name)
+		return
+	}
+	r.fail[name] = err
+}
+
+// Calls returns a copy of the calls recorded so far
+func (r *RecordingActions) Calls() []ActionCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ActionCall(nil), r.calls...)
+}
+
+// Count returns how many times the named action was called
+func (r *RecordingActions) Count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, c := range r.calls {
+		if c.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *RecordingActions) record(name string, args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, ActionCall{Name: name, Args: args})
+	return r.fail[name]
+}
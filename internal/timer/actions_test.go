@@ -0,0 +1,146 @@
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+)
+
+func TestFailedSuspendStillStartsBreak(t *testing.T) {
+	tm, clock, actions, _ := newTestTimer(t, nil)
+	actions.FailWith("suspend", errors.New("inhibited"))
+	events, cancel := tm.Subscribe()
+	defer cancel()
+
+	tm.Start()
+	clock.Advance(50 * time.Minute)
+	waitFor(t, "the break", func() bool { return tm.GetState() == StateBreak })
+
+	if n := actions.Count("suspend"); n != 1 {
+		t.Fatalf("suspend called %d times, want 1", n)
+	}
+	if err := tm.Err(); err == nil || !strings.Contains(err.Error(), "inhibited") {
+		t.Fatalf("Err() = %v, want the suspend failure", err)
+	}
+	if st := tm.Status(); !strings.Contains(st.Error, "inhibited") {
+		t.Fatalf("Status().Error = %q, want the suspend failure", st.Error)
+	}
+
+	var failed bool
+	for len(events) > 0 {
+		ev := <-events
+		if ev.Kind == EventError {
+			failed = true
+			if !strings.Contains(ev.Message, "suspend failed: inhibited") {
+				t.Fatalf("error event message = %q", ev.Message)
+			}
+		}
+	}
+	if !failed {
+		t.Fatal("no error event for the failed suspend")
+	}
+
+	// The break is taken awake, in full
+	if got, want := tm.GetRemainingTime(), 10*time.Minute; got != want {
+		t.Fatalf("break remaining = %s, want %s", got, want)
+	}
+
+	// A new session starts with a clean slate
+	clock.Advance(10 * time.Minute)
+	tm.Start()
+	if err := tm.Err(); err != nil {
+		t.Fatalf("Err() = %v after a new start, want nil", err)
+	}
+}
+
+func TestFailedNotificationIsReported(t *testing.T) {
+	tm, clock, actions, _ := newTestTimer(t, nil)
+	actions.FailWith("notify", errors.New("no notification daemon"))
+
+	tm.Start()
+	clock.Advance(45 * time.Minute)
+
+	expectState(t, tm, StateWarning)
+	if err := tm.Err(); err == nil {
+		t.Fatal("Err() = nil after a failed notification")
+	}
+
+	actions.FailWith("notify", nil)
+	calls := actions.Calls()
+	if len(calls) != 1 || calls[0].Name != "notify" || calls[0].Args[0] != "Pomoduru" {
+		t.Fatalf("calls = %v, want the warning notification", calls)
+	}
+}
+
+func TestEndActions(t *testing.T) {
+	tests := []struct {
+		action string
+		args   []string
+		call   string // Recorded by RecordingActions, or "" for none
+		sleeps bool
+	}{
+		{config.ActionSuspend, []string{"-i"}, "suspend", true},
+		{config.ActionHibernate, []string{"-i"}, "hibernate", true},
+		{config.ActionHybridSleep, nil, "hybrid-sleep", true},
+		{config.ActionLock, []string{"self"}, "lock", false},
+		{config.ActionBlank, nil, "blank", false},
+		{config.ActionCommand, []string{"xset", "dpms", "force", "off"}, "command", false},
+		{config.ActionOverlay, nil, "", false},
+	}
+
+	for _, tt := range tests {
+		for _, fail := range []bool{false, true} {
+			if fail && tt.call == "" {
+				continue
+			}
+			t.Run(fmt.Sprintf("%s, failing %v", tt.action, fail), func(t *testing.T) {
+				cfg := config.DefaultConfig()
+				cfg.EndAction = tt.action
+				cfg.EndActionArgs = tt.args
+				tm, clock, actions, watcher := newTestTimer(t, cfg)
+				if fail {
+					actions.FailWith(tt.call, errors.New("not allowed"))
+				}
+
+				tm.Start()
+				clock.Advance(cfg.WorkDuration)
+				if tt.sleeps && !fail {
+					waitFor(t, "the resume watcher", func() bool { return watcher.Waiting() == 1 })
+					expectState(t, tm, StateSuspended)
+					watcher.Resume(0)
+				}
+				waitFor(t, "the break", func() bool { return tm.GetState() == StateBreak })
+
+				// The action alone is run, with the configured arguments
+				var run []ActionCall
+				for _, call := range actions.Calls() {
+					if call.Name != "notify" {
+						run = append(run, call)
+					}
+				}
+				want := []ActionCall{{Name: tt.call, Args: tt.args}}
+				if tt.call == "" {
+					want = nil
+				}
+				if !slices.EqualFunc(run, want, func(a, b ActionCall) bool {
+					return a.Name == b.Name && slices.Equal(a.Args, b.Args)
+				}) {
+					t.Fatalf("ran %v, want %v", run, want)
+				}
+
+				err := tm.Err()
+				if fail && (err == nil || !strings.Contains(err.Error(), "not allowed")) {
+					t.Fatalf("Err() = %v, want the failure", err)
+				}
+				if !fail && err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+			})
+		}
+	}
+}
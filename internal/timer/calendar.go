@@ -0,0 +1,84 @@
+package timer
+
+import (
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/ical"
+)
+
+// Calendar reports busy times, such as meetings, that work sessions
+// should end before
+type Calendar interface {
+	// Busy returns the busy spans overlapping from to to, sorted by start
+	Busy(from, to time.Time) ([]ical.Span, error)
+}
+
+// WithCalendar makes the timer fit work sessions around c's busy times:
+// a session that would run into one is cut short to end before it, and
+// the machine isn't put to sleep while one is under way
+func WithCalendar(c Calendar) Option {
+	return func(t *Timer) {
+		t.calendar = c
+	}
+}
+
+// minCalendarWork is the shortest work session worth fitting in before a
+// busy time. With less time left, the session runs its full length.
+const minCalendarWork = 10 * time.Minute
+
+// busy returns the busy spans overlapping the next d. Must be called with
+// mu held.
+func (t *Timer) busy(d time.Duration) []ical.Span {
+	if t.calendar == nil {
+		return nil
+	}
+
+	now := t.clock.Now()
+	spans, err := t.calendar.Busy(now, now.Add(d))
+	// Log each problem once, not on every check
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	if msg != "" && msg != t.calendarErr {
+		t.logger.Printf("calendar: %s", msg)
+	}
+	t.calendarErr = msg
+	return spans
+}
+
+// workLength returns how long a work session starting now lasts: the
+// configured work duration, or less to end before a busy time. Must be
+// called with mu held.
+func (t *Timer) workLength() time.Duration {
+	d := t.config.WorkDuration
+	now := t.clock.Now()
+
+	for _, span := range t.busy(d) {
+		if !span.Start.After(now) {
+			// Already under way
+			continue
+		}
+		if fit := span.Start.Sub(now); fit >= minCalendarWork {
+			t.logger.Printf("shortening work to %s to end before %q", fit.Round(time.Second), span.Summary)
+			return fit
+		}
+		break
+	}
+	return d
+}
+
+// meeting returns the busy time under way, if any, including one starting
+// right now as a session shortened by workLength ends. Must be called
+// with mu held.
+func (t *Timer) meeting() (ical.Span, bool) {
+	now := t.clock.Now()
+	// Busy leaves out spans starting at the end of the range, so look a
+	// little ahead
+	for _, span := range t.busy(suspendGrace) {
+		if !span.Start.After(now) && span.End.After(now) {
+			return span, true
+		}
+	}
+	return ical.Span{}, false
+}
@@ -0,0 +1,101 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/ical"
+)
+
+// fakeCalendar is busy at fixed spans, found by a real ical.Calendar so
+// that spans at the edges of a range count as they do for .ics files
+type fakeCalendar []ical.Span
+
+func (c fakeCalendar) Busy(from, to time.Time) ([]ical.Span, error) {
+	cal := &ical.Calendar{}
+	for _, span := range c {
+		cal.Events = append(cal.Events, ical.Event{Summary: span.Summary, Start: span.Start, End: span.End})
+	}
+	return cal.Busy(from, to), nil
+}
+
+func TestWorkLength(t *testing.T) {
+	meeting := func(in, length time.Duration) ical.Span {
+		return ical.Span{Summary: "Meeting", Start: testStart.Add(in), End: testStart.Add(in + length)}
+	}
+
+	tests := []struct {
+		name  string
+		spans fakeCalendar
+		want  time.Duration
+	}{
+		{"free", nil, 50 * time.Minute},
+		{"ends before a meeting", fakeCalendar{meeting(30*time.Minute, time.Hour)}, 30 * time.Minute},
+		{"too little time before a meeting", fakeCalendar{meeting(5*time.Minute, time.Hour)}, 50 * time.Minute},
+		{"meeting after the session", fakeCalendar{meeting(time.Hour, time.Hour)}, 50 * time.Minute},
+		{"meeting under way", fakeCalendar{meeting(-10*time.Minute, 30*time.Minute)}, 50 * time.Minute},
+		{
+			"meeting under way, then another",
+			fakeCalendar{meeting(-10*time.Minute, 30*time.Minute), meeting(40*time.Minute, time.Hour)},
+			40 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, _, _, _ := newTestTimer(t, nil, WithCalendar(tt.spans))
+			tm.Start()
+			if got := tm.Status().Total; got != tt.want {
+				t.Fatalf("work session lasts %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoSuspendDuringMeeting(t *testing.T) {
+	tests := []struct {
+		name    string
+		cal     fakeCalendar
+		suspend bool
+	}{
+		{"free", nil, true},
+		{
+			// The session is cut short to end just as the meeting starts
+			"meeting starting as work ends",
+			fakeCalendar{{Summary: "Meeting", Start: testStart.Add(45 * time.Minute), End: testStart.Add(2 * time.Hour)}},
+			false,
+		},
+		{
+			"meeting under way",
+			fakeCalendar{{Summary: "Meeting", Start: testStart.Add(-10 * time.Minute), End: testStart.Add(2 * time.Hour)}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, clock, actions, watcher := newTestTimer(t, nil, WithCalendar(tt.cal))
+
+			tm.Start()
+			clock.Advance(tm.Status().Total)
+
+			if tt.suspend {
+				expectState(t, tm, StateSuspended)
+				waitFor(t, "the suspend", func() bool {
+					return actions.Count("suspend") == 1 && watcher.Waiting() == 1
+				})
+				return
+			}
+
+			// The break starts at once, not after suspendGrace, and
+			// nothing was started to take the machine away
+			expectState(t, tm, StateBreak)
+			if n := actions.Count("suspend"); n != 0 {
+				t.Fatalf("suspended %d times during the meeting, want 0", n)
+			}
+			if n := watcher.Waiting(); n != 0 {
+				t.Fatalf("%d waiting for a resume, want none", n)
+			}
+		})
+	}
+}
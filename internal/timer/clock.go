@@ -0,0 +1,155 @@
+package timer
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for Timer and Scheduler. The real clock
+// defers to the time package; FakeClock lets tests drive a whole cycle
+// without waiting for it.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	AfterFunc(d time.Duration, f func()) Stopper
+	After(d time.Duration) <-chan time.Time
+}
+
+// Stopper cancels a pending AfterFunc. Stop reports whether the call
+// prevented the function from running.
+type Stopper interface {
+	Stop() bool
+}
+
+// RealClock returns a Clock backed by the time package
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+func (realClock) AfterFunc(d time.Duration, f func()) Stopper {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is a manually advanced Clock. Functions scheduled with
+// AfterFunc run synchronously from Advance, in deadline order, with the
+// clock set to their deadline.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	seq     uint64
+}
+
+type fakeWaiter struct {
+	clock    *FakeClock
+	deadline time.Time
+	seq      uint64
+	fn       func()
+}
+
+// NewFakeClock creates a fake clock reading start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the fake current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// AfterFunc schedules f to run once the clock has been advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Stopper {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	w := &fakeWaiter{clock: c, deadline: c.now.Add(d), seq: c.seq, fn: f}
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// After returns a channel that receives the fake time once the clock has
+// been advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() { ch <- c.Now() })
+	return ch
+}
+
+// Advance moves the clock forward by d, running every function that
+// falls due along the way
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for c.fireNext(target) {
+	}
+
+	c.mu.Lock()
+	c.now = target
+	c.mu.Unlock()
+}
+
+// Pending returns the number of scheduled functions that have not run yet
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// fireNext runs the earliest function due at or before target, and
+// reports whether there was one.
+func (c *FakeClock) fireNext(target time.Time) bool {
+	c.mu.Lock()
+	sort.Slice(c.waiters, func(i, j int) bool {
+		a, b := c.waiters[i], c.waiters[j]
+		if a.deadline.Equal(b.deadline) {
+			return a.seq < b.seq
+		}
+		return a.deadline.Before(b.deadline)
+	})
+	if len(c.waiters) == 0 || c.waiters[0].deadline.After(target) {
+		c.mu.Unlock()
+		return false
+	}
+
+	w := c.waiters[0]
+	c.waiters = c.waiters[1:]
+	if w.deadline.After(c.now) {
+		c.now = w.deadline
+	}
+	c.mu.Unlock()
+
+	w.fn()
+	return true
+}
+
+func (w *fakeWaiter) Stop() bool {
+	c := w.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
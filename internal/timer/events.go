@@ -0,0 +1,93 @@
+package timer
+
+import (
+	"time"
+)
+
+// EventKind identifies what happened to the timer
+type EventKind string
+
+const (
+	EventStarted        EventKind = "started"         // A work session began
+	EventWarningStarted EventKind = "warning_started" // The end of the work session is near
+	EventExtended       EventKind = "extended"        // The work session was extended
+	EventPaused         EventKind = "paused"
+	EventResumed        EventKind = "resumed"
+	EventSuspending     EventKind = "suspending"    // The machine is being put to sleep
+	EventBreakStarted   EventKind = "break_started" // A break or long break began
+	EventBreakSkipped   EventKind = "break_skipped" // The machine slept through the break
+	EventBreakEnded     EventKind = "break_ended"   // A break ran out and the timer went idle
+	EventStopped        EventKind = "stopped"       // The timer was stopped and went idle
+	EventWindowEnded    EventKind = "window_ended"  // Work stopped because its schedule window ended
+	EventScheduled      EventKind = "scheduled"     // The Scheduler's next start or stop changed
+	EventRestored       EventKind = "restored"      // A session saved by a previous process was picked up
+	EventReconfigured   EventKind = "reconfigured"  // The config was reloaded, or couldn't be; see Message
+	EventError          EventKind = "error"         // A system action failed; see Message
+)
+
+// Event is a timer transition, delivered to subscribers in order
+type Event struct {
+	Kind    EventKind `json:"kind"`
+	Time    time.Time `json:"time"`
+	Status  Status    `json:"status"` // The timer right after the event
+	Message string    `json:"message,omitempty"`
+}
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before it starts missing them
+const subscriberBuffer = 64
+
+// Subscribe returns a channel receiving every event from now on, and a
+// function that ends the subscription and closes the channel. Events are
+// dropped rather than stalling the timer if the channel isn't drained.
+func (t *Timer) Subscribe() (<-chan Event, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	if t.subscribers == nil {
+		t.subscribers = make(map[chan Event]struct{})
+	}
+	t.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// emit delivers an event about the current state to every subscriber.
+// Sending while mu is held keeps events in order. Must be called with mu
+// held.
+func (t *Timer) emit(kind EventKind, message string) {
+	t.dirty = true
+
+	ev := Event{
+		Kind:    kind,
+		Time:    t.clock.Now(),
+		Status:  t.status(),
+		Message: message,
+	}
+	for ch := range t.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// stateEvents maps the state entered by begin to the event announcing it
+var stateEvents = map[State]EventKind{
+	StateWorking:   EventStarted,
+	StateExtended:  EventExtended,
+	StateSuspended: EventSuspending,
+	StateBreak:     EventBreakStarted,
+	StateLongBreak: EventBreakStarted,
+	StateIdle:      EventStopped,
+}
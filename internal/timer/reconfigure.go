@@ -0,0 +1,50 @@
+package timer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/ical"
+)
+
+// Reconfigure switches the timer to cfg, such as a config reloaded after
+// it was edited, and returns the settings that changed, announcing them
+// with EventReconfigured. The session under way keeps its length; new
+// durations apply from the next one.
+func (t *Timer) Reconfigure(cfg *config.Config) []string {
+	t.mu.Lock()
+	defer t.unlock()
+
+	changes := t.config.Changes(cfg)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if !slices.Equal(t.config.Calendars, cfg.Calendars) {
+		t.calendar, t.calendarErr = nil, ""
+		if len(cfg.Calendars) > 0 {
+			t.calendar = ical.OpenFiles(cfg.Calendars...)
+		}
+	}
+	t.config = cfg
+
+	t.emit(EventReconfigured, "Settings reloaded: "+strings.Join(changes, ", "))
+	return changes
+}
+
+// RejectConfig announces with EventReconfigured that a changed config
+// couldn't be loaded, so the timer carries on with the one it has
+func (t *Timer) RejectConfig(err error) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	t.emit(EventReconfigured, "Settings not reloaded: "+err.Error())
+}
+
+// getCalendar returns the calendar, which Reconfigure may replace
+func (t *Timer) getCalendar() Calendar {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calendar
+}
@@ -0,0 +1,228 @@
+package timer
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResumeWatcher reports when the machine comes back from sleep, so the
+// break can be counted from the moment the user is actually back.
+type ResumeWatcher interface {
+	// WaitResume blocks until the machine resumes from a sleep that began
+	// after the call, and returns how long it was asleep. It returns false
+	// if cancel is closed first.
+	WaitResume(cancel <-chan struct{}) (slept time.Duration, ok bool)
+}
+
+// DefaultResumeWatcher watches for logind's PrepareForSleep signal when
+// gdbus is available, and for wall-clock jumps in any case; whichever
+// notices the resume first wins.
+func DefaultResumeWatcher() ResumeWatcher {
+	watchers := FirstResume{ClockJumpWatcher{}}
+	if _, err := exec.LookPath("gdbus"); err == nil {
+		watchers = append(watchers, LogindWatcher{})
+	}
+	return watchers
+}
+
+// ClockJumpWatcher detects a resume by comparing wall-clock time with the
+// monotonic clock, which stands still while the machine is asleep.
+type ClockJumpWatcher struct {
+	Interval  time.Duration // How often to sample the clocks (default 1s)
+	Threshold time.Duration // Smallest gap that counts as a sleep (default 5s)
+
+	// read samples wall-clock time and monotonic time elapsed since a
+	// fixed point; the time package's clocks if nil
+	read func() (wall time.Time, mono time.Duration)
+}
+
+// WaitResume implements ResumeWatcher
+func (w ClockJumpWatcher) WaitResume(cancel <-chan struct{}) (time.Duration, bool) {
+	interval, threshold := w.Interval, w.Threshold
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if threshold <= 0 {
+		threshold = 5 * time.Second
+	}
+
+	read := w.read
+	if read == nil {
+		start := time.Now()
+		read = func() (time.Time, time.Duration) {
+			now := time.Now()
+			return now.Round(0), now.Sub(start)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prevWall, prevMono := read()
+	for {
+		select {
+		case <-cancel:
+			return 0, false
+		case <-ticker.C:
+		}
+
+		wall, mono := read()
+		if slept := wall.Sub(prevWall) - (mono - prevMono); slept >= threshold {
+			return slept, true
+		}
+		prevWall, prevMono = wall, mono
+	}
+}
+
+// LogindWatcher follows logind's PrepareForSleep signal through
+// gdbus monitor on the system bus.
+type LogindWatcher struct{}
+
+// WaitResume implements ResumeWatcher. It gives up, returning false, if
+// the monitor cannot be started.
+func (LogindWatcher) WaitResume(cancel <-chan struct{}) (time.Duration, bool) {
+	cmd := exec.Command("gdbus", "monitor", "--system",
+		"--dest", "org.freedesktop.login1",
+		"--object-path", "/org/freedesktop/login1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, false
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, false
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cancel:
+		case <-done:
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	var sleptAt time.Time
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, "PrepareForSleep") {
+			continue
+		}
+
+		// Wall-clock time, which keeps counting while asleep
+		now := time.Now().Round(0)
+		switch {
+		case strings.Contains(line, "(true,)"):
+			sleptAt = now
+		case strings.Contains(line, "(false,)") && !sleptAt.IsZero():
+			return now.Sub(sleptAt), true
+		}
+	}
+	return 0, false
+}
+
+// FirstResume combines watchers, reporting whichever sees the resume first
+type FirstResume []ResumeWatcher
+
+// WaitResume implements ResumeWatcher
+func (ws FirstResume) WaitResume(cancel <-chan struct{}) (time.Duration, bool) {
+	done := make(chan struct{})
+	defer close(done)
+
+	// Stop the others once one has answered, or when cancelled
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+		case <-done:
+		}
+		close(stop)
+	}()
+
+	resumed := make(chan time.Duration, len(ws))
+	var wg sync.WaitGroup
+	for _, w := range ws {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if slept, ok := w.WaitResume(stop); ok {
+				resumed <- slept
+			}
+		}()
+	}
+
+	// Every watcher giving up counts as a cancel
+	gaveUp := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(gaveUp)
+	}()
+
+	select {
+	case slept := <-resumed:
+		return slept, true
+	case <-gaveUp:
+		select {
+		case slept := <-resumed:
+			return slept, true
+		default:
+			return 0, false
+		}
+	case <-cancel:
+		return 0, false
+	}
+}
+
+// FakeResumeWatcher is a ResumeWatcher resumed by hand from tests
+type FakeResumeWatcher struct {
+	mu      sync.Mutex
+	waiters []chan time.Duration
+}
+
+// WaitResume implements ResumeWatcher
+func (f *FakeResumeWatcher) WaitResume(cancel <-chan struct{}) (time.Duration, bool) {
+	ch := make(chan time.Duration, 1)
+	f.mu.Lock()
+	f.waiters = append(f.waiters, ch)
+	f.mu.Unlock()
+
+	select {
+	case slept := <-ch:
+		return slept, true
+	case <-cancel:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, w := range f.waiters {
+			if w == ch {
+				f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+				break
+			}
+		}
+		return 0, false
+	}
+}
+
+// Waiting returns the number of callers blocked in WaitResume
+func (f *FakeResumeWatcher) Waiting() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// Resume wakes every caller blocked in WaitResume, reporting that the
+// machine slept for slept
+func (f *FakeResumeWatcher) Resume(slept time.Duration) {
+	f.mu.Lock()
+	waiters := f.waiters
+	f.waiters = nil
+	f.mu.Unlock()
+
+	for _, ch := range waiters {
+		ch <- slept
+	}
+}
@@ -0,0 +1,132 @@
+package timer
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+// fakeReadings returns a read func for ClockJumpWatcher that advances the
+// monotonic clock by a second per sample and the wall clock by a second
+// plus the next of jumps. Once they run out, both clocks stand still.
+func fakeReadings(jumps ...time.Duration) func() (time.Time, time.Duration) {
+	var mu sync.Mutex
+	wall, mono, n := testStart, time.Duration(0), 0
+	return func() (time.Time, time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		if n > 0 && n <= len(jumps) {
+			wall = wall.Add(time.Second + jumps[n-1])
+			mono += time.Second
+		}
+		n++
+		return wall, mono
+	}
+}
+
+func TestClockJumpWatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		jumps   []time.Duration
+		slept   time.Duration
+		resumed bool
+	}{
+		{"sleep", []time.Duration{0, 0, 10 * time.Minute}, 10 * time.Minute, true},
+		{"at the threshold", []time.Duration{5 * time.Second}, 5 * time.Second, true},
+		{"drift", []time.Duration{time.Second, 2 * time.Second, time.Second}, 0, false},
+		{"clock set back", []time.Duration{-time.Hour}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := ClockJumpWatcher{Interval: time.Millisecond, read: fakeReadings(tt.jumps...)}
+			cancel := make(chan struct{})
+			if !tt.resumed {
+				// Long after every reading has been taken
+				time.AfterFunc(100*time.Millisecond, func() { close(cancel) })
+			}
+
+			slept, ok := w.WaitResume(cancel)
+			if slept != tt.slept || ok != tt.resumed {
+				t.Fatalf("WaitResume = %s, %v, want %s, %v", slept, ok, tt.slept, tt.resumed)
+			}
+		})
+	}
+}
+
+func TestSleepThroughBreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		slept time.Duration
+		state State
+		left  time.Duration
+	}{
+		{"short sleep", 5 * time.Minute, StateBreak, 10 * time.Minute},
+		{"as long as the break", 10 * time.Minute, StateIdle, 0},
+		{"longer than the break", 2 * time.Hour, StateIdle, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := history.Open(filepath.Join(t.TempDir(), "history.jsonl"))
+			tm, clock, _, watcher := newTestTimer(t, nil, WithHistory(store))
+			events, cancel := tm.Subscribe()
+			defer cancel()
+
+			tm.Start()
+			clock.Advance(50 * time.Minute)
+			waitFor(t, "the resume watcher", func() bool { return watcher.Waiting() == 1 })
+			watcher.Resume(tt.slept)
+			waitFor(t, "the resume", func() bool { return tm.GetState() != StateSuspended })
+
+			// A short sleep leaves the whole break, counted from now
+			st := tm.Status()
+			if st.State != tt.state || st.Remaining != tt.left {
+				t.Fatalf("after sleeping %s: %s with %s left, want %s with %s left",
+					tt.slept, st.State, st.Remaining, tt.state, tt.left)
+			}
+
+			var skipped, ended bool
+			for len(events) > 0 {
+				switch ev := <-events; ev.Kind {
+				case EventBreakSkipped:
+					skipped = true
+				case EventBreakEnded:
+					ended = true
+				}
+			}
+			if want := tt.state == StateIdle; skipped != want || ended != want {
+				t.Fatalf("break skipped %v and ended %v, want %v", skipped, ended, want)
+			}
+
+			entries, err := store.Read(history.Filter{Kind: history.KindBreak})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.state == StateIdle && (len(entries) != 1 || entries[0].Outcome != history.OutcomeSkipped) {
+				t.Fatalf("break history %+v, want one skipped break", entries)
+			}
+		})
+	}
+}
+
+func TestSleepThroughBreakAlwaysOn(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AlwaysOn = true
+	tm, clock, _, watcher := newTestTimer(t, cfg)
+
+	tm.Start()
+	clock.Advance(50 * time.Minute)
+	waitFor(t, "the resume watcher", func() bool { return watcher.Waiting() == 1 })
+	watcher.Resume(time.Hour)
+
+	// Back to work at once, for a whole session
+	waitFor(t, "the next session", func() bool { return tm.GetState() == StateWorking })
+	if got := tm.GetRemainingTime(); got != 50*time.Minute {
+		t.Fatalf("%s left of the next session, want 50m", got)
+	}
+}
@@ -1,88 +1,427 @@
 package timer
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
-
+	
 	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/cron"
 )
 
-// Scheduler manages automatic timer scheduling
+// Scheduler starts and stops work sessions to follow the configured
+// schedule. Rather than polling, it sleeps until the next window boundary
+// or cron time, and plans again whenever the timer changes, the machine
+// wakes up or Reschedule is called.
 type Scheduler struct {
 	config *config.Config
 	timer  *Timer
-	active bool
+	clock  Clock
+	wake   chan struct{} // Makes the loop plan again straight away
+	
+	mu        sync.Mutex
+	parent    context.Context    // What Start was given, for starting again
+	cancel    context.CancelFunc // Ends the loop; nil when not running
+	done      chan struct{}      // Closed once the loop has returned
+	staged    *config.Config     // From Reconfigure, for the loop to switch to
+	nextStart time.Time
+	nextStop  time.Time
+	
+	// The rest belongs to the loop
+	warned map[string]bool // Configuration problems already logged
+	
+	// lastCron is when cron schedules were last checked; those due since
+	// then fire on the next check
+	lastCron time.Time
+	
+	// held is when the window in which work was stopped by hand ends; work
+	// isn't started again before then
+	held time.Time
+	
+	// workday is the start of the day of the window now open, and zero
+	// outside the windows
+	workday time.Time
 }
 
-// NewScheduler creates a new scheduler
+// NewScheduler creates a new scheduler driving t. It reads time from the
+// same Clock as t.
 func NewScheduler(cfg *config.Config, t *Timer) *Scheduler {
 	return &Scheduler{
 		config: cfg,
 		timer:  t,
-		active: false,
+		clock:  t.clock,
+		wake:   make(chan struct{}, 1),
+		warned: make(map[string]bool),
 	}
 }
 
-// Start begins the scheduling system
-func (s *Scheduler) Start() {
-	if !s.config.ScheduleEnabled || s.active {
+// Start runs the scheduler in the background until ctx is done or Stop
+// is called. It does nothing if scheduling is disabled or the scheduler
+// is already running.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.parent = ctx
+	if s.cancel != nil || !s.config.ScheduleEnabled {
 		return
 	}
 	
-	s.active = true
-	go s.scheduleLoop()
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.run(ctx, s.done)
 }
 
-// Stop stops the scheduling system
+// Stop stops the scheduler and waits for its loop to return. Work under
+// way carries on.
 func (s *Scheduler) Stop() {
-	s.active = false
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+	
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Reschedule makes the scheduler plan again straight away, as it should
+// after the configuration changes
+func (s *Scheduler) Reschedule() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Reconfigure switches the scheduler to cfg, such as a config reloaded
+// after it was edited, and plans again straight away. The scheduler stops
+// if scheduling has been disabled, and starts again, under the context
+// last given to Start, if it has been enabled.
+func (s *Scheduler) Reconfigure(cfg *config.Config) {
+	s.mu.Lock()
+	if s.cancel != nil && cfg.ScheduleEnabled {
+		s.staged = cfg
+		s.mu.Unlock()
+		s.Reschedule()
+		return
+	}
+	s.mu.Unlock()
+	
+	s.Stop()
+	s.mu.Lock()
+	s.config, s.staged = cfg, nil
+	parent := s.parent
+	s.mu.Unlock()
+	
+	if parent != nil {
+		s.Start(parent)
+	}
+}
+
+// NextStart returns when the scheduler next starts a work session, or
+// the zero time if it has nothing planned
+func (s *Scheduler) NextStart() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextStart
+}
+
+// NextStop returns when the scheduler stops the work under way at the end
+// of its window, or the zero time if it won't
+func (s *Scheduler) NextStop() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextStop
 }
 
-// scheduleLoop runs the scheduling logic
-func (s *Scheduler) scheduleLoop() {
-	for s.active {
-		now := time.Now()
+// run is the scheduler's loop
+func (s *Scheduler) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	
+	events, unsubscribe := s.timer.Subscribe()
+	defer unsubscribe()
+	
+	// Clocks stand still while the machine is asleep, so a wait can
+	// overrun by however long it slept
+	go s.watchResume(ctx)
+	
+	s.lastCron = s.clock.Now()
+	for {
+		s.mu.Lock()
+		if s.staged != nil {
+			s.config, s.staged = s.staged, nil
+		}
+		s.mu.Unlock()
 		
-		// Parse schedule times
-		startTime, err := time.Parse("15:04", s.config.ScheduleStart)
-		if err != nil {
-			// Invalid time format, skip this iteration
-			time.Sleep(time.Minute)
-			continue
+		now := s.clock.Now()
+		start, stop := s.check(now)
+		s.publish(start, stop)
+		
+		var alarm Stopper
+		if wake := earliest(start, stop); !wake.IsZero() {
+			alarm = s.clock.AfterFunc(wake.Sub(now), s.Reschedule)
 		}
 		
-		endTime, err := time.Parse("15:04", s.config.ScheduleEnd)
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case ev := <-events:
+			s.observe(ev)
+		}
+		if alarm != nil {
+			alarm.Stop()
+		}
+		
+		if ctx.Err() != nil {
+			s.publish(time.Time{}, time.Time{})
+			return
+		}
+	}
+}
+
+// watchResume wakes the loop whenever the machine resumes from sleep,
+// until ctx is done
+func (s *Scheduler) watchResume(ctx context.Context) {
+	for {
+		if _, ok := s.timer.watcher.WaitResume(ctx.Done()); !ok {
+			return
+		}
+		s.Reschedule()
+	}
+}
+
+// observe notes work stopped by hand during a window, so that it isn't
+// started again until the next one, and work started again by hand
+func (s *Scheduler) observe(ev Event) {
+	switch ev.Kind {
+	case EventStopped:
+		sched, loc := s.config.WeeklySchedule(), s.location()
+		if _, end, ok := windowAt(sched, ev.Time, loc); ok {
+			s.held = windowEnd(sched, end, loc)
+		}
+	case EventStarted:
+		s.held = time.Time{}
+	}
+}
+
+// check starts or stops work as the schedule says, and returns when the
+// scheduler next starts and stops work (zero for neither)
+func (s *Scheduler) check(now time.Time) (start, stop time.Time) {
+	// Cron schedules replace the windows
+	if len(s.config.ScheduleCron) > 0 {
+		return s.checkCron(now), time.Time{}
+	}
+	
+	// Always-on work carries on by itself
+	if s.config.AlwaysOn {
+		return time.Time{}, time.Time{}
+	}
+	return s.checkWindows(now)
+}
+
+// checkWindows starts work in a window, unless it was stopped by hand or
+// the calendar is busy, and winds it down once the window is over. Work
+// started by hand outside the windows is left alone.
+func (s *Scheduler) checkWindows(now time.Time) (start, stop time.Time) {
+	sched := s.config.WeeklySchedule()
+	loc := s.location()
+	
+	from, end, within := windowAt(sched, now, loc)
+	if !within {
+		start, _, _ = nextWindow(sched, now, loc)
+		if !s.workday.IsZero() {
+			s.endWindow(start, loc)
+		}
+		return start, time.Time{}
+	}
+	
+	if s.workday.IsZero() {
+		// Work left to wind down from an earlier window carries on
+		s.timer.carryOn()
+	}
+	year, month, day := from.Date()
+	s.workday = time.Date(year, month, day, 0, 0, 0, 0, loc)
+	
+	end = windowEnd(sched, end, loc)
+	after, _, _ := nextWindow(sched, end, loc)
+	if s.timer.GetState() != StateIdle || now.Before(s.held) {
+		return after, end
+	}
+	
+	if retry := s.holdOff(now); !retry.IsZero() {
+		if retry.Before(end) {
+			return retry, end
+		}
+		return after, end
+	}
+	
+	s.timer.logger.Printf("schedule: starting work until %s", end.In(loc).Format("15:04"))
+	s.timer.Start()
+	return after, end
+}
+
+// endWindow winds down the work under way as a window closes, following
+// the end-of-day policy. The workday is over, and summed up, unless the
+// next window starts on the same day.
+func (s *Scheduler) endWindow(next time.Time, loc *time.Location) {
+	workday := s.workday
+	s.workday = time.Time{}
+	
+	if !next.IsZero() && next.In(loc).Format(config.DateLayout) == workday.Format(config.DateLayout) {
+		workday = time.Time{}
+	}
+	
+	policy := s.config.EndOfDay
+	if policy == "" {
+		policy = config.EndOfDayFinish
+	}
+	if s.timer.GetState() != StateIdle {
+		s.timer.logger.Printf("schedule: window over, stopping work (%s)", policy)
+	}
+	s.timer.WindDown(policy, workday)
+}
+
+// checkCron starts a work session if a cron schedule fired since the
+// last check, unless one is already under way, and returns when one next
+// fires. A start held back by the calendar stays due until it can happen.
+func (s *Scheduler) checkCron(now time.Time) time.Time {
+	loc := s.location()
+	since := s.lastCron.In(loc)
+	
+	var scheds []*cron.Schedule
+	for _, expr := range s.config.ScheduleCron {
+		sched, err := cron.Parse(expr)
 		if err != nil {
-			time.Sleep(time.Minute)
+			s.warn(err.Error())
 			continue
 		}
-		
-		// Create today's schedule times
-		today := now.Truncate(24 * time.Hour)
-		scheduledStart := today.Add(time.Duration(startTime.Hour())*time.Hour + time.Duration(startTime.Minute())*time.Minute)
-		scheduledEnd := today.Add(time.Duration(endTime.Hour())*time.Hour + time.Duration(endTime.Minute())*time.Minute)
-		
-		// If end time is before start time, it means it spans midnight
-		if scheduledEnd.Before(scheduledStart) {
-			scheduledEnd = scheduledEnd.Add(24 * time.Hour)
+		scheds = append(scheds, sched)
+	}
+	
+	due := ""
+	for _, sched := range scheds {
+		if next := sched.Next(since); !next.IsZero() && !next.After(now) {
+			due = sched.String()
+			break
 		}
-		
-		// Check if we're within schedule
-		withinSchedule := now.After(scheduledStart) && now.Before(scheduledEnd)
-		
-		// If we're within schedule and timer is idle, start it
-		if withinSchedule && s.timer.GetState() == StateIdle && !s.config.AlwaysOn {
-			s.timer.Start()
+	}
+	
+	// A break is part of the cycle under way, so it isn't cut short
+	if due != "" && !s.config.Schedule.IsException(now.In(loc)) && s.timer.GetState() == StateIdle {
+		if retry := s.holdOff(now); !retry.IsZero() {
+			return retry
 		}
-		
-		// If we're outside schedule and timer is running (not always-on), stop it
-		if !withinSchedule && s.timer.GetState() != StateIdle && !s.config.AlwaysOn {
-			// Only stop if we're not in a break or extended state
-			if s.timer.GetState() == StateWorking || s.timer.GetState() == StateWarning {
-				s.timer.Stop()
+		s.timer.logger.Printf("schedule: %q fired, starting work", due)
+		s.timer.Start()
+	}
+	s.lastCron = now
+	return s.nextCron(scheds, now.In(loc))
+}
+
+// nextCron returns the first time after t that one of scheds fires on a
+// day that isn't off, or the zero time if none ever does
+func (s *Scheduler) nextCron(scheds []*cron.Schedule, t time.Time) time.Time {
+	// Each pass either finds a time or skips a day off
+	for range len(s.config.Schedule.Exceptions) + 1 {
+		var next time.Time
+		for _, sched := range scheds {
+			if n := sched.Next(t); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+				next = n
 			}
 		}
+		if next.IsZero() || !s.config.Schedule.IsException(next) {
+			return next
+		}
 		
-		// Sleep for a minute before checking again
-		time.Sleep(time.Minute)
+		// Carry on from the last minute of the day off
+		year, month, day := next.Date()
+		t = time.Date(year, month, day+1, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+	}
+	return time.Time{}
+}
+
+// holdOff returns when the calendar next leaves room for a work session,
+// if something busy is under way or starts too soon for a worthwhile one,
+// and the zero time if work can start now
+func (s *Scheduler) holdOff(now time.Time) time.Time {
+	calendar := s.timer.getCalendar()
+	if calendar == nil {
+		return time.Time{}
+	}
+	
+	spans, err := calendar.Busy(now, now.Add(minCalendarWork))
+	if err != nil {
+		s.warn(err.Error())
+	}
+	if len(spans) == 0 {
+		return time.Time{}
+	}
+	
+	span := spans[0]
+	s.warn(fmt.Sprintf("holding off until %q ends at %s", span.Summary, span.End.In(s.location()).Format("15:04")))
+	return span.End
+}
+
+// publish records the plan for NextStart and NextStop, and passes it on
+// to the timer's status
+func (s *Scheduler) publish(start, stop time.Time) {
+	s.mu.Lock()
+	s.nextStart, s.nextStop = start, stop
+	s.mu.Unlock()
+	
+	loc := s.location()
+	var plan []string
+	if !start.IsZero() {
+		plan = append(plan, "next start "+start.In(loc).Format("Mon 15:04"))
+	}
+	if !stop.IsZero() {
+		plan = append(plan, "stop "+stop.In(loc).Format("Mon 15:04"))
+	}
+	if len(plan) == 0 {
+		plan = append(plan, "nothing planned")
+	}
+	s.timer.plan(start, stop, strings.Join(plan, ", "))
+}
+
+// earliest returns the earlier of two times, ignoring zero ones
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// location returns the time zone of the schedule, falling back to local
+// time if the configured zone is unknown
+func (s *Scheduler) location() *time.Location {
+	loc, err := s.config.Location()
+	if err != nil {
+		s.warn(err.Error() + ", using local time")
+	}
+	return loc
+}
+
+// warn logs a configuration problem the first time it is seen
+func (s *Scheduler) warn(msg string) {
+	if !s.warned[msg] {
+		s.warned[msg] = true
+		s.timer.logger.Printf("schedule: %s", msg)
+	}
+}
+
+// plan records the Scheduler's next start and stop for Status, announcing
+// a change with message
+func (t *Timer) plan(start, stop time.Time, message string) {
+	t.mu.Lock()
+	defer t.unlock()
+	
+	if start.Equal(t.nextStart) && stop.Equal(t.nextStop) {
+		return
 	}
+	t.nextStart, t.nextStop = start, stop
+	t.emit(EventScheduled, message)
 }
@@ -0,0 +1,157 @@
+package timer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+)
+
+func TestCronLeavesBreaksAlone(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ScheduleEnabled = true
+	cfg.ScheduleCron = []string{"0 11 * * *"}
+	cfg.EndAction = config.ActionLock
+	cfg.BreakDuration = 20 * time.Minute
+	tm, clock, _, _ := newTestTimer(t, cfg)
+	s := NewScheduler(cfg, tm)
+
+	// Work from 10:00 to 10:50, then a break until 11:10 over the 11:00
+	// trigger
+	s.lastCron = clock.Now()
+	tm.Start()
+	clock.Advance(55 * time.Minute)
+	expectState(t, tm, StateBreak)
+
+	clock.Advance(5 * time.Minute)
+	next := s.checkCron(clock.Now())
+	expectState(t, tm, StateBreak)
+	if want := testStart.Add(25 * time.Hour); !next.Equal(want) {
+		t.Fatalf("next cron = %s, want %s", next, want)
+	}
+
+	// Idle, the next trigger starts work
+	tm.Stop()
+	clock.Advance(24 * time.Hour)
+	s.checkCron(clock.Now())
+	expectState(t, tm, StateWorking)
+}
+
+// runScheduler runs s until the test ends
+func runScheduler(t *testing.T, s *Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.Stop()
+	})
+}
+
+// waitPlan waits for the scheduler to publish start and stop into the
+// timer's status, which it does after every check
+func waitPlan(t *testing.T, tm *Timer, start, stop time.Time) {
+	t.Helper()
+	waitFor(t, fmt.Sprintf("next start %s, stop %s", start, stop), func() bool {
+		st := tm.Status()
+		return st.NextStart.Equal(start) && st.NextStop.Equal(stop)
+	})
+}
+
+// windowConfig returns a config scheduling work on Mondays from start to
+// end
+func windowConfig(start, end string) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.ScheduleEnabled = true
+	cfg.Schedule = config.Schedule{Windows: []config.Window{{Days: []string{"mon"}, Start: start, End: end}}}
+	cfg.EndAction = config.ActionLock
+	cfg.EndOfDay = config.EndOfDayNow
+	return cfg
+}
+
+func TestSchedulerFollowsWindow(t *testing.T) {
+	cfg := windowConfig("10:30", "11:00")
+	tm, clock, _, _ := newTestTimer(t, cfg)
+	s := NewScheduler(cfg, tm)
+	runScheduler(t, s)
+
+	nextWeek := testStart.Add(7*24*time.Hour + 30*time.Minute)
+	waitPlan(t, tm, testStart.Add(30*time.Minute), time.Time{})
+	expectState(t, tm, StateIdle)
+
+	clock.Advance(30 * time.Minute)
+	waitFor(t, "work at the window start", func() bool { return tm.GetState() == StateWorking })
+	waitPlan(t, tm, nextWeek, testStart.Add(time.Hour))
+	if got, want := s.NextStop(), testStart.Add(time.Hour); !got.Equal(want) {
+		t.Fatalf("NextStop = %s, want %s", got, want)
+	}
+
+	clock.Advance(30 * time.Minute)
+	waitFor(t, "work to stop at the window end", func() bool { return tm.GetState() == StateIdle })
+	waitPlan(t, tm, nextWeek, time.Time{})
+
+	// Nothing is planned once the scheduler stops
+	s.Stop()
+	if st := tm.Status(); !st.NextStart.IsZero() || !st.NextStop.IsZero() {
+		t.Fatalf("plan after Stop = %s, %s, want none", st.NextStart, st.NextStop)
+	}
+}
+
+func TestSchedulerHeldAfterManualStop(t *testing.T) {
+	cfg := windowConfig("10:00", "12:00")
+	tm, clock, _, _ := newTestTimer(t, cfg)
+	s := NewScheduler(cfg, tm)
+	runScheduler(t, s)
+
+	nextWeek := testStart.Add(7 * 24 * time.Hour)
+	waitFor(t, "work inside the window", func() bool { return tm.GetState() == StateWorking })
+	waitPlan(t, tm, nextWeek, testStart.Add(2*time.Hour))
+
+	tm.Stop()
+	// Once the loop has seen the stop, plan again with a later end to
+	// know that it has checked since
+	waitFor(t, "the stop to be seen", func() bool {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		for ch := range tm.subscribers {
+			if len(ch) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+	later := windowConfig("10:00", "12:30")
+	s.Reconfigure(later)
+	waitPlan(t, tm, nextWeek, testStart.Add(150*time.Minute))
+	expectState(t, tm, StateIdle)
+
+	// Held until the window is over, then back to work in the next one
+	clock.Advance(150 * time.Minute)
+	waitPlan(t, tm, nextWeek, time.Time{})
+	expectState(t, tm, StateIdle)
+
+	clock.Advance(nextWeek.Sub(clock.Now()))
+	waitFor(t, "work in the next window", func() bool { return tm.GetState() == StateWorking })
+}
+
+func TestSchedulerWakesAfterResume(t *testing.T) {
+	cfg := windowConfig("11:00", "12:00")
+	tm, clock, _, watcher := newTestTimer(t, cfg)
+	s := NewScheduler(cfg, tm)
+	runScheduler(t, s)
+
+	waitPlan(t, tm, testStart.Add(time.Hour), time.Time{})
+	waitFor(t, "the scheduler to watch for resumes", func() bool { return watcher.Waiting() == 1 })
+
+	// Asleep over the window start: the clock moves on, but timers stand
+	// still
+	clock.mu.Lock()
+	clock.now = clock.now.Add(90 * time.Minute)
+	clock.mu.Unlock()
+	expectState(t, tm, StateIdle)
+
+	watcher.Resume(90 * time.Minute)
+	waitFor(t, "work after the resume", func() bool { return tm.GetState() == StateWorking })
+}
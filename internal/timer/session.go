@@ -0,0 +1,74 @@
+package timer
+
+import (
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+// Recorder receives an entry for every finished work and break session
+type Recorder interface {
+	Append(e history.Entry) error
+}
+
+// WithHistory makes the timer record every finished session to r
+func WithHistory(r Recorder) Option {
+	return func(t *Timer) {
+		t.history = r
+	}
+}
+
+// openSession starts tracking a session of kind planned to last d. Must
+// be called with mu held.
+func (t *Timer) openSession(kind string, d time.Duration) {
+	t.session = &history.Entry{
+		Kind:    kind,
+		Start:   t.clock.Now(),
+		Planned: d,
+		Cycle:   t.completed + 1,
+	}
+	if kind != history.KindWork {
+		t.session.Cycle = t.completed
+	}
+}
+
+// closeSession finishes the tracked session, if any, as of now. Must be
+// called with mu held, before the transition that ends the session.
+func (t *Timer) closeSession(outcome string) {
+	t.closeSessionAt(outcome, t.clock.Now())
+}
+
+// closeSessionAt finishes the tracked session, if any, as of end and
+// queues it for the history. Must be called with mu held.
+func (t *Timer) closeSessionAt(outcome string, end time.Time) {
+	e := t.session
+	if e == nil {
+		return
+	}
+	t.session = nil
+
+	if t.paused {
+		e.Paused += end.Sub(t.pausedAt)
+	}
+	e.End = end
+	e.Actual = max(end.Sub(e.Start)-e.Paused, 0)
+	e.Outcome = outcome
+
+	if t.history == nil {
+		return
+	}
+	entry := *e
+	t.later(func() {
+		if err := t.history.Append(entry); err != nil {
+			t.logger.Printf("recording history failed: %v", err)
+		}
+	})
+}
+
+// breakKind returns the history kind for a break state
+func breakKind(state State) string {
+	if state == StateLongBreak {
+		return history.KindLongBreak
+	}
+	return history.KindBreak
+}
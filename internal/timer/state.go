@@ -0,0 +1,76 @@
+package timer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+// savedState is the on-disk form of a timer's in-flight session
+type savedState struct {
+	State         State          `json:"state"`
+	StartTime     time.Time      `json:"start_time"`
+	PhaseDuration time.Duration  `json:"phase_duration"`
+	ExtendUsed    bool           `json:"extend_used"`
+	Completed     int            `json:"completed"`
+	Paused        bool           `json:"paused"`
+	PausedAt      time.Time      `json:"paused_at"`
+	PausedTotal   time.Duration  `json:"paused_total"`
+	Session       *history.Entry `json:"session,omitempty"`
+}
+
+// stateFile persists savedState snapshots. Snapshots are numbered so one
+// taken earlier but written later can't overwrite a newer one.
+type stateFile struct {
+	path string
+
+	mu      sync.Mutex
+	written uint64
+}
+
+// save atomically replaces the file with s, unless a snapshot newer than
+// seq has already been written
+func (f *stateFile) save(seq uint64, s savedState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if seq <= f.written {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	f.written = seq
+	return nil
+}
+
+// load reads the last saved snapshot
+func (f *stateFile) load() (savedState, error) {
+	var s savedState
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
@@ -0,0 +1,127 @@
+package timer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+)
+
+// restartTimer starts a timer for cfg at at from the state file at path,
+// as a new process would
+func restartTimer(t *testing.T, cfg *config.Config, path string, at time.Time) (*Timer, *FakeClock, *RecordingActions) {
+	t.Helper()
+	clock := NewFakeClock(at)
+	actions := &RecordingActions{}
+	tm := NewTimer(cfg,
+		WithClock(clock),
+		WithActions(actions),
+		WithResumeWatcher(&FakeResumeWatcher{}),
+		WithStateFile(path),
+	)
+	return tm, clock, actions
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(tm *Timer, clock *FakeClock) // Before going down
+		down      time.Duration                     // From the start of work to the restart
+		state     State
+		paused    bool
+		remaining time.Duration
+	}{
+		{
+			name:      "mid-session",
+			run:       func(tm *Timer, clock *FakeClock) { clock.Advance(20 * time.Minute) },
+			down:      25 * time.Minute,
+			state:     StateWorking,
+			remaining: 25 * time.Minute,
+		},
+		{
+			name:      "within the warning",
+			run:       func(tm *Timer, clock *FakeClock) { clock.Advance(20 * time.Minute) },
+			down:      47 * time.Minute,
+			state:     StateWarning,
+			remaining: 3 * time.Minute,
+		},
+		{
+			name: "paused",
+			run: func(tm *Timer, clock *FakeClock) {
+				clock.Advance(10 * time.Minute)
+				tm.Pause()
+			},
+			down:      40 * time.Minute,
+			state:     StateWorking,
+			paused:    true,
+			remaining: 40 * time.Minute,
+		},
+		{
+			// Wrapped up as if asleep for the 5 minutes since
+			name:      "deadline passed, break left",
+			run:       func(tm *Timer, clock *FakeClock) { clock.Advance(20 * time.Minute) },
+			down:      55 * time.Minute,
+			state:     StateBreak,
+			remaining: 10 * time.Minute,
+		},
+		{
+			name:  "deadline and break passed",
+			run:   func(tm *Timer, clock *FakeClock) { clock.Advance(20 * time.Minute) },
+			down:  65 * time.Minute,
+			state: StateIdle,
+		},
+		{
+			name: "suspended",
+			run: func(tm *Timer, clock *FakeClock) {
+				clock.Advance(50 * time.Minute)
+				expectState(t, tm, StateSuspended)
+			},
+			down:      52 * time.Minute,
+			state:     StateBreak,
+			remaining: 10 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			tm, clock, _, _ := newTestTimer(t, nil, WithStateFile(path))
+			tm.Start()
+			tt.run(tm, clock)
+
+			restored, _, _ := restartTimer(t, config.DefaultConfig(), path, testStart.Add(tt.down))
+			st := restored.Status()
+			if st.State != tt.state || st.Paused != tt.paused || st.Remaining != tt.remaining {
+				t.Fatalf("restored %s, paused %v, %s left, want %s, paused %v, %s left",
+					st.State, st.Paused, st.Remaining, tt.state, tt.paused, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestRestoredSessionCarriesOn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	cfg := config.DefaultConfig()
+	cfg.EndAction = config.ActionLock
+	tm, clock, _, _ := newTestTimer(t, cfg, WithStateFile(path))
+	tm.Start()
+	clock.Advance(20 * time.Minute)
+	tm.Pause()
+
+	// Deadlines are armed afresh, from the resume
+	restored, clock, actions := restartTimer(t, cfg, path, testStart.Add(time.Hour))
+	if !restored.Resume() {
+		t.Fatal("restored session not paused")
+	}
+	clock.Advance(25 * time.Minute)
+	expectState(t, restored, StateWarning)
+	clock.Advance(5 * time.Minute)
+	expectState(t, restored, StateBreak)
+	if n := actions.Count("lock"); n != 1 {
+		t.Fatalf("locked %d times, want 1", n)
+	}
+	if n := clock.Pending(); n != 1 {
+		t.Fatalf("%d deadlines pending, want the end of the break", n)
+	}
+}
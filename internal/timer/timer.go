@@ -1,10 +1,15 @@
 package timer
 
 import (
-	"os/exec"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/history"
 )
 
 // State represents the current timer state
@@ -17,120 +22,543 @@ const (
 	StateBreak
 	StateExtended
 	StateSuspended
+	StateLongBreak
 )
 
+var stateNames = map[State]string{
+	StateIdle:      "idle",
+	StateWorking:   "working",
+	StateWarning:   "warning",
+	StateBreak:     "break",
+	StateExtended:  "extended",
+	StateSuspended: "suspended",
+	StateLongBreak: "long_break",
+}
+
+// String returns the state's name as used in the state file and the
+// control protocol
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("state(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *State) UnmarshalText(text []byte) error {
+	for state, name := range stateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown timer state %q", text)
+}
+
+// Status is a snapshot of everything a timer's display needs
+type Status struct {
+	State      State         `json:"state"`
+	Remaining  time.Duration `json:"remaining"`
+	Total      time.Duration `json:"total"` // Full length of the current countdown
+	Paused     bool          `json:"paused"`
+	ExtendUsed bool          `json:"extend_used"`
+	Cycle      int           `json:"cycle"`
+	Error      string        `json:"error,omitempty"` // Last failed system action
+
+	// The Scheduler's plan: when it next starts a work session, and when
+	// it stops the one under way at the end of a window
+	NextStart time.Time `json:"next_start,omitzero"`
+	NextStop  time.Time `json:"next_stop,omitzero"`
+}
+
 // Timer manages the pomodoro timer
 type Timer struct {
+	mu            sync.Mutex
 	config        *config.Config
+	clock         Clock
+	actions       SystemActions
+	watcher       ResumeWatcher
+	history       Recorder
+	calendar      Calendar
+	calendarErr   string // Last calendar error logged
+	logger        *log.Logger
 	state         State
 	startTime     time.Time
-	extendUsed    bool // Track if extension has been used this cycle
-	onStateChange func(State, time.Duration) // Callback for state changes
+	phaseDuration time.Duration  // Length of the current state's countdown
+	extendUsed    bool           // Track if extension has been used this cycle
+	warningTime   time.Duration  // Warning lead of the current work session, kept through reloads
+	completed     int            // Work sessions finished since the last long break
+	paused        bool           // Countdown frozen by Pause
+	pausedAt      time.Time      // When the current pause began
+	pausedTotal   time.Duration  // Time spent paused this cycle
+	session       *history.Entry // Work or break session in progress
+	lastErr       error          // Most recent failed system action
+	nextStart     time.Time      // Scheduler's next start, reported in Status
+	nextStop      time.Time      // Scheduler's next stop, reported in Status
+	windDown      string         // End-of-day policy waiting for the pomodoro or break to end
+	workday       time.Time      // Start of the workday to sum up once wound down
+
+	// pending holds the timers armed for the current state. They are all
+	// cancelled on every transition, and gen is bumped so that a callback
+	// which already fired but is still waiting for mu becomes a no-op.
+	pending []Stopper
+	gen     uint64
+
+	// deferred holds work queued while mu is held (callbacks, shelling out)
+	// that must run only after it is released.
+	deferred []func()
+
+	// subscribers receive every Event
+	subscribers map[chan Event]struct{}
+
+	// store, if set, receives a snapshot of the session whenever it changes
+	// (dirty), numbered by saved.
+	store *stateFile
+	dirty bool
+	saved uint64
+}
+
+// Option configures optional Timer dependencies
+type Option func(*Timer)
+
+// WithClock makes the timer read time from c instead of the real clock
+func WithClock(c Clock) Option {
+	return func(t *Timer) {
+		t.clock = c
+	}
+}
+
+// WithActions makes the timer notify and suspend through a instead of
+// shelling out
+func WithActions(a SystemActions) Option {
+	return func(t *Timer) {
+		t.actions = a
+	}
+}
+
+// WithResumeWatcher makes the timer learn about resumes from suspend
+// through w
+func WithResumeWatcher(w ResumeWatcher) Option {
+	return func(t *Timer) {
+		t.watcher = w
+	}
+}
+
+// WithStateFile makes the timer save its session to path on every
+// transition, and pick up a session left there by a previous process
+func WithStateFile(path string) Option {
+	return func(t *Timer) {
+		t.store = &stateFile{path: path}
+	}
+}
+
+// WithLogger makes the timer log failed system actions to l
+func WithLogger(l *log.Logger) Option {
+	return func(t *Timer) {
+		t.logger = l
+	}
 }
 
 // NewTimer creates a new timer instance
-func NewTimer(cfg *config.Config) *Timer {
-	return &Timer{
+func NewTimer(cfg *config.Config, opts ...Option) *Timer {
+	t := &Timer{
 		config:     cfg,
+		clock:      RealClock(),
+		actions:    ExecActions(),
+		watcher:    DefaultResumeWatcher(),
+		logger:     log.New(io.Discard, "", 0),
 		state:      StateIdle,
 		extendUsed: false,
 	}
-}
+	for _, opt := range opts {
+		opt(t)
+	}
 
-// SetStateChangeCallback sets the callback for state changes
-func (t *Timer) SetStateChangeCallback(callback func(State, time.Duration)) {
-	t.onStateChange = callback
+	if t.store != nil {
+		t.mu.Lock()
+		t.restore()
+		t.unlock()
+	}
+	return t
 }
 
 // Start begins the pomodoro timer
 func (t *Timer) Start() {
-	t.state = StateWorking
-	t.startTime = time.Now()
-	t.extendUsed = false
-	
-	if t.onStateChange != nil {
-		t.onStateChange(t.state, t.config.WorkDuration)
-	}
-	
-	// Start the warning timer
-	warningDuration := t.config.WorkDuration - t.config.WarningTime
-	time.AfterFunc(warningDuration, t.handleWarning)
-	
-	// Start the work timer
-	time.AfterFunc(t.config.WorkDuration, t.handleWorkComplete)
+	t.mu.Lock()
+	t.start()
+	t.unlock()
+}
+
+// StartFor begins a one-off work session lasting d instead of the
+// configured work duration
+func (t *Timer) StartFor(d time.Duration) {
+	t.mu.Lock()
+	t.startFor(d)
+	t.unlock()
 }
 
 // Extend extends the current work session by the configured extend duration
 // Returns true if extension was allowed, false if already used
 func (t *Timer) Extend() bool {
-	if t.extendUsed || t.state != StateWarning {
+	t.mu.Lock()
+	defer t.unlock()
+
+	if t.extendUsed || t.state != StateWarning || t.paused {
 		return false
 	}
-	
+
 	t.extendUsed = true
-	t.state = StateExtended
-	
-	// Cancel the existing timers and create new ones
-	time.AfterFunc(t.config.ExtendDuration, t.handleExtendedWorkComplete)
-	
-	if t.onStateChange != nil {
-		t.onStateChange(t.state, t.config.ExtendDuration)
-	}
-	
+	if t.session != nil {
+		t.session.Extended = true
+		t.session.Planned += t.config.ExtendDuration
+	}
+	t.begin(StateExtended, t.config.ExtendDuration)
+
+	return true
+}
+
+// Pause freezes the countdown of a work or break session. It returns false
+// if there is nothing to pause, the timer is already paused, or the
+// configured pause allowance for this cycle has been used up.
+func (t *Timer) Pause() bool {
+	t.mu.Lock()
+	defer t.unlock()
+
+	switch t.state {
+	case StateWorking, StateWarning, StateExtended, StateBreak, StateLongBreak:
+	default:
+		return false
+	}
+
+	limit := t.config.MaxPauseDuration
+	if t.paused || (limit > 0 && t.pausedTotal >= limit) {
+		return false
+	}
+
+	t.transition(t.state)
+	t.paused = true
+	t.pausedAt = t.clock.Now()
+
+	t.armPauseLimit()
+
+	t.emit(EventPaused, "")
+	return true
+}
+
+// Resume restarts a paused countdown where it left off. It returns false
+// if the timer is not paused.
+func (t *Timer) Resume() bool {
+	t.mu.Lock()
+	defer t.unlock()
+
+	if !t.paused {
+		return false
+	}
+
+	t.resume()
 	return true
 }
 
 // Stop stops the timer and resets to idle state
 func (t *Timer) Stop() {
-	t.state = StateIdle
-	t.extendUsed = false
-	
-	if t.onStateChange != nil {
-		t.onStateChange(t.state, 0)
-	}
+	t.mu.Lock()
+	t.stop(EventStopped)
+	t.unlock()
 }
 
 // GetState returns the current timer state
 func (t *Timer) GetState() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.state
 }
 
 // GetRemainingTime returns the remaining time in current state
 func (t *Timer) GetRemainingTime() time.Duration {
-	if t.state == StateIdle {
-		return 0
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining()
+}
+
+// Status returns a consistent snapshot of the timer
+func (t *Timer) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status()
+}
+
+// status implements Status. Must be called with mu held.
+func (t *Timer) status() Status {
+	s := Status{
+		State:      t.state,
+		Remaining:  t.remaining(),
+		Total:      t.phaseDuration,
+		Paused:     t.paused,
+		ExtendUsed: t.extendUsed,
+		Cycle:      t.cycle(),
+		NextStart:  t.nextStart,
+		NextStop:   t.nextStop,
 	}
-	
-	var totalDuration time.Duration
+	if t.lastErr != nil {
+		s.Error = t.lastErr.Error()
+	}
+	return s
+}
+
+// Cycle returns the position of the current pomodoro within a set of
+// LongBreakEvery, counting from 1. During a break it is the position of
+// the session that just finished.
+func (t *Timer) Cycle() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cycle()
+}
+
+// cycle implements Cycle. Must be called with mu held.
+func (t *Timer) cycle() int {
 	switch t.state {
-	case StateWorking, StateWarning:
-		totalDuration = t.config.WorkDuration
-	case StateExtended:
-		totalDuration = t.config.ExtendDuration
-	case StateBreak:
-		totalDuration = t.config.BreakDuration
+	case StateSuspended, StateBreak, StateLongBreak:
+		return t.completed
 	}
-	
-	elapsed := time.Since(t.startTime)
-	return totalDuration - elapsed
+	return t.completed + 1
+}
+
+// Paused returns whether the countdown is currently paused
+func (t *Timer) Paused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.paused
 }
 
 // ExtendUsed returns whether extension has been used this cycle
 func (t *Timer) ExtendUsed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.extendUsed
 }
 
+// Err returns the most recent system action failure of the current
+// session, or nil. It is cleared when a new session starts.
+func (t *Timer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastErr
+}
+
+// start begins a work session. Must be called with mu held.
+func (t *Timer) start() {
+	t.startFor(t.workLength())
+}
+
+// startFor begins a work session lasting d. Must be called with mu held.
+func (t *Timer) startFor(d time.Duration) {
+	t.closeSession(history.OutcomeStopped)
+
+	t.extendUsed = false
+	t.pausedTotal = 0
+	t.lastErr = nil
+	t.windDown, t.workday = "", time.Time{}
+	t.warningTime = t.config.WarningTime
+	t.begin(StateWorking, d)
+	t.openSession(history.KindWork, d)
+}
+
+// stop resets the timer to idle, announcing it with the event kind.
+// Must be called with mu held.
+func (t *Timer) stop(kind EventKind) {
+	t.closeSession(history.OutcomeStopped)
+	t.transition(StateIdle)
+	t.extendUsed = false
+	t.windDown, t.workday = "", time.Time{}
+
+	t.emit(kind, "")
+}
+
+// begin enters state with a countdown of d and arms its deadlines. Must
+// be called with mu held.
+func (t *Timer) begin(state State, d time.Duration) {
+	t.transition(state)
+	t.startTime = t.clock.Now()
+	t.phaseDuration = d
+
+	t.emit(stateEvents[state], "")
+	t.arm(d)
+}
+
+// arm schedules the deadlines of the current state given how much of its
+// countdown is left. Must be called with mu held.
+func (t *Timer) arm(remaining time.Duration) {
+	switch t.state {
+	case StateWorking:
+		// Start the warning timer
+		t.schedule(remaining-t.warningTime, t.handleWarning)
+
+		// Start the work timer
+		t.schedule(remaining, t.handleWorkComplete)
+	case StateWarning:
+		t.schedule(remaining, t.handleWorkComplete)
+	case StateExtended:
+		t.schedule(remaining, t.handleExtendedWorkComplete)
+	case StateBreak, StateLongBreak:
+		t.schedule(remaining, t.handleBreakComplete)
+	}
+}
+
+// armPauseLimit resumes the current pause once it has used up what is
+// left of the cycle's allowance. Must be called with mu held.
+func (t *Timer) armPauseLimit() {
+	limit := t.config.MaxPauseDuration
+	if limit <= 0 {
+		return
+	}
+
+	left := limit - t.pausedTotal - t.clock.Since(t.pausedAt)
+	t.schedule(left, func() {
+		t.perform("notification", func() error {
+			return t.actions.Notify("Pomoduru", "Pause limit reached, resuming the timer.")
+		})
+		t.resume()
+	})
+}
+
+// resume ends the current pause, shifting the countdown by its length.
+// Must be called with mu held.
+func (t *Timer) resume() {
+	pausedFor := t.clock.Since(t.pausedAt)
+	t.pausedTotal += pausedFor
+	t.startTime = t.startTime.Add(pausedFor)
+	if t.session != nil {
+		t.session.Paused += pausedFor
+	}
+
+	t.transition(t.state)
+
+	t.emit(EventResumed, "")
+	t.arm(t.remaining())
+}
+
+// remaining returns the time left in the current state's countdown. Must
+// be called with mu held.
+func (t *Timer) remaining() time.Duration {
+	if t.state == StateIdle {
+		return 0
+	}
+
+	now := t.clock.Now()
+	if t.paused {
+		now = t.pausedAt
+	}
+	return t.phaseDuration - now.Sub(t.startTime)
+}
+
+// transition moves the timer to state, cancelling everything that was
+// scheduled for the previous one. Must be called with mu held.
+func (t *Timer) transition(state State) {
+	for _, p := range t.pending {
+		p.Stop()
+	}
+	t.pending = t.pending[:0]
+	t.gen++
+	t.state = state
+	t.paused = false
+}
+
+// schedule runs fn with mu held after d, unless the timer transitions to
+// another state first. Must be called with mu held.
+func (t *Timer) schedule(d time.Duration, fn func()) {
+	gen := t.gen
+	t.pending = append(t.pending, t.clock.AfterFunc(d, func() {
+		t.mu.Lock()
+		defer t.unlock()
+		if gen == t.gen {
+			fn()
+		}
+	}))
+}
+
+// cancelled returns a channel that is closed on the next transition. Must
+// be called with mu held.
+func (t *Timer) cancelled() <-chan struct{} {
+	ch := make(chan struct{})
+	t.pending = append(t.pending, closer(ch))
+	return ch
+}
+
+// closer is a Stopper that closes a channel
+type closer chan struct{}
+
+func (c closer) Stop() bool {
+	close(c)
+	return true
+}
+
+// later queues fn to run once mu has been released. Must be called with
+// mu held.
+func (t *Timer) later(fn func()) {
+	t.deferred = append(t.deferred, fn)
+}
+
+// unlock releases mu and then runs the work deferred while it was held,
+// so callbacks are free to call back into the timer.
+func (t *Timer) unlock() {
+	if t.dirty && t.store != nil {
+		t.saved++
+		seq, snapshot := t.saved, t.snapshot()
+		t.later(func() {
+			if err := t.store.save(seq, snapshot); err != nil {
+				t.logger.Printf("saving timer state failed: %v", err)
+			}
+		})
+	}
+	t.dirty = false
+
+	deferred := t.deferred
+	t.deferred = nil
+	t.mu.Unlock()
+
+	for _, fn := range deferred {
+		fn()
+	}
+}
+
+// perform runs a system action once mu has been released, recording and
+// logging a failure. Must be called with mu held.
+func (t *Timer) perform(what string, action func() error) {
+	t.later(func() {
+		if err := action(); err != nil {
+			t.fail(what, err)
+		}
+	})
+}
+
+// fail records and logs a failed system action
+func (t *Timer) fail(what string, err error) {
+	t.mu.Lock()
+	t.lastErr = err
+	t.emit(EventError, fmt.Sprintf("%s failed: %v", what, err))
+	t.unlock()
+
+	t.logger.Printf("%s failed: %v", what, err)
+}
+
 // handleWarning is called when warning time is reached
 func (t *Timer) handleWarning() {
 	if t.state == StateWorking {
 		t.state = StateWarning
-		
-		// Send notification
-		exec.Command("notify-send", "Pomoduru", "System will sleep in 5 minutes! Use 'Extend' to delay.").Run()
-		
-		if t.onStateChange != nil {
-			t.onStateChange(t.state, t.config.WarningTime)
-		}
+
+		// Send notification, worded while mu is held as Reconfigure may
+		// replace the config before it goes out
+		msg := fmt.Sprintf("%s in %d minutes! Use 'Extend' to delay.", endActionWarning(t.config.EndAction), int(t.warningTime.Minutes()))
+		t.perform("notification", func() error {
+			return t.actions.Notify("Pomoduru", msg)
+		})
+
+		t.emit(EventWarningStarted, "")
 	}
 }
 
@@ -148,47 +576,283 @@ func (t *Timer) handleExtendedWorkComplete() {
 	}
 }
 
-// suspendSystem suspends the system
+// suspendSystem ends the work session with the configured end action
 func (t *Timer) suspendSystem() {
-	t.state = StateSuspended
-	
-	if t.onStateChange != nil {
-		t.onStateChange(t.state, 0)
+	if t.session != nil {
+		t.session.Action = t.config.EndAction
 	}
-	
+	t.closeSession(history.OutcomeCompleted)
+	t.completed++
+
+	if t.windDown == config.EndOfDayBreak {
+		t.stopForDay()
+		return
+	}
+
 	// Send final notification
-	exec.Command("notify-send", "Pomoduru", "Time's up! Taking a break...").Run()
-	
-	// Suspend system
-	exec.Command("systemctl", "suspend", "-i").Run()
-	
-	// After suspend and resume, start break timer
-	time.AfterFunc(time.Second, func() {
+	t.perform("notification", func() error {
+		return t.actions.Notify("Pomoduru", "Time's up! Taking a break...")
+	})
+
+	action := t.config.EndAction
+	run := t.endAction(action, t.config.EndActionArgs)
+
+	// Only the sleep actions take the machine away; for the rest the
+	// break starts right now
+	switch action {
+	case config.ActionSuspend, config.ActionHibernate, config.ActionHybridSleep:
+		if span, ok := t.meeting(); ok {
+			// Don't take the machine away in the middle of a meeting
+			t.logger.Printf("not running %s during %q", action, span.Summary)
+			t.startBreak()
+			return
+		}
+	default:
+		if run != nil {
+			t.perform(action, run)
+		}
 		t.startBreak()
+		return
+	}
+
+	t.begin(StateSuspended, 0)
+	gen, cancel := t.gen, t.cancelled()
+
+	// Stay suspended until the machine is actually back. The suspend
+	// command returns as soon as the request is queued, so the watcher
+	// has to be listening before it runs.
+	t.later(func() {
+		go func() {
+			resumed := make(chan time.Duration, 1)
+			go func() {
+				if slept, ok := t.watcher.WaitResume(cancel); ok {
+					resumed <- slept
+				}
+			}()
+
+			if err := run(); err != nil {
+				// Never went to sleep, so take the break awake
+				t.fail(action, err)
+				t.afterSleep(gen, 0)
+				return
+			}
+
+			select {
+			case slept := <-resumed:
+				t.afterSleep(gen, slept)
+			case <-cancel:
+			}
+		}()
 	})
+
+	// If the machine hasn't gone to sleep by now, something inhibited it.
+	// Monotonic timers stand still while asleep, so this can't fire early.
+	t.schedule(suspendGrace, func() {
+		t.resumeFromSleep(0)
+	})
+}
+
+// suspendGrace is how long to wait for the machine to go to sleep before
+// starting the break anyway
+const suspendGrace = time.Minute
+
+// afterSleep starts the break once the machine has resumed, unless the
+// timer moved on in the meantime
+func (t *Timer) afterSleep(gen uint64, slept time.Duration) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	if gen == t.gen && t.state == StateSuspended {
+		t.resumeFromSleep(slept)
+	}
+}
+
+// resumeFromSleep starts the break counting from now, or skips it when the
+// machine already slept for longer than the break. Must be called with mu
+// held.
+func (t *Timer) resumeFromSleep(slept time.Duration) {
+	state, d := t.nextBreak()
+	if slept >= d && slept > 0 {
+		t.logger.Printf("slept for %s, skipping the %s break", slept.Round(time.Second), d)
+		t.openSession(breakKind(state), d)
+		t.closeSession(history.OutcomeSkipped)
+		t.emit(EventBreakSkipped, fmt.Sprintf("slept for %s", slept.Round(time.Second)))
+		t.endBreak(state)
+		return
+	}
+	t.beginBreak(state, d)
+}
+
+// endAction returns the system action for a configured end action, or nil
+// if it is handled by the UI alone
+func (t *Timer) endAction(action string, args []string) func() error {
+	switch action {
+	case config.ActionSuspend:
+		return func() error { return t.actions.Suspend(args...) }
+	case config.ActionHibernate:
+		return func() error { return t.actions.Hibernate(args...) }
+	case config.ActionHybridSleep:
+		return func() error { return t.actions.HybridSleep(args...) }
+	case config.ActionLock:
+		return func() error { return t.actions.Lock(args...) }
+	case config.ActionBlank:
+		return func() error { return t.actions.Blank(args...) }
+	case config.ActionCommand:
+		return func() error { return t.actions.Run(args...) }
+	case config.ActionOverlay:
+		return nil
+	}
+
+	// Fall back to the original behaviour for anything unrecognised
+	return func() error { return t.actions.Suspend(args...) }
+}
+
+// endActionWarning describes what is about to happen for the warning
+// notification
+func endActionWarning(action string) string {
+	switch action {
+	case config.ActionHibernate:
+		return "System will hibernate"
+	case config.ActionLock:
+		return "Screen will lock"
+	case config.ActionBlank:
+		return "Screen will turn off"
+	case config.ActionOverlay, config.ActionCommand:
+		return "Break starts"
+	}
+	return "System will sleep"
 }
 
 // startBreak starts the break period
 func (t *Timer) startBreak() {
-	t.state = StateBreak
-	t.startTime = time.Now()
-	
-	if t.onStateChange != nil {
-		t.onStateChange(t.state, t.config.BreakDuration)
+	t.beginBreak(t.nextBreak())
+}
+
+// beginBreak enters a break state lasting d. Must be called with mu held.
+func (t *Timer) beginBreak(state State, d time.Duration) {
+	t.begin(state, d)
+	t.openSession(breakKind(state), d)
+}
+
+// nextBreak returns the kind and length of the break due now, a long one
+// if the work session just finished completes a set
+func (t *Timer) nextBreak() (State, time.Duration) {
+	if every := t.config.LongBreakEvery; every > 0 && t.completed >= every {
+		return StateLongBreak, t.config.LongBreakDuration
 	}
-	
-	// Start break timer
-	time.AfterFunc(t.config.BreakDuration, t.handleBreakComplete)
+	return StateBreak, t.config.BreakDuration
 }
 
 // handleBreakComplete is called when break time is complete
 func (t *Timer) handleBreakComplete() {
-	if t.state == StateBreak {
-		// If always-on mode, restart the cycle
-		if t.config.AlwaysOn {
-			t.Start()
-		} else {
-			t.Stop()
+	if t.state == StateBreak || t.state == StateLongBreak {
+		t.endBreak(t.state)
+	}
+}
+
+// endBreak closes a break of the given kind and moves on to the next
+// cycle. Must be called with mu held.
+func (t *Timer) endBreak(kind State) {
+	t.closeSession(history.OutcomeCompleted)
+
+	if kind == StateLongBreak {
+		// A long break closes the set
+		t.completed = 0
+	}
+
+	if t.windDown != "" {
+		t.stopForDay()
+		return
+	}
+
+	// If always-on mode, restart the cycle
+	if t.config.AlwaysOn {
+		t.start()
+	} else {
+		t.stop(EventBreakEnded)
+	}
+}
+
+// snapshot captures the session for the state file. Must be called with
+// mu held.
+func (t *Timer) snapshot() savedState {
+	s := savedState{
+		State:         t.state,
+		StartTime:     t.startTime,
+		PhaseDuration: t.phaseDuration,
+		ExtendUsed:    t.extendUsed,
+		Completed:     t.completed,
+		Paused:        t.paused,
+		PausedAt:      t.pausedAt,
+		PausedTotal:   t.pausedTotal,
+	}
+	if t.session != nil {
+		session := *t.session
+		s.Session = &session
+	}
+	return s
+}
+
+// restore picks up the session saved by a previous process, rescheduling
+// what is left of it. A session whose deadline passed while we were down
+// is wrapped up as if the machine had been asleep for that long. Must be
+// called with mu held.
+func (t *Timer) restore() {
+	s, err := t.store.load()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.logger.Printf("loading timer state failed: %v", err)
 		}
+		return
+	}
+
+	t.completed = s.Completed
+	if s.State == StateIdle {
+		return
 	}
+
+	t.transition(s.State)
+	t.startTime = s.StartTime
+	t.phaseDuration = s.PhaseDuration
+	t.extendUsed = s.ExtendUsed
+	t.pausedTotal = s.PausedTotal
+	t.session = s.Session
+	// A new process reads the config afresh, warning included
+	t.warningTime = t.config.WarningTime
+
+	if s.Paused {
+		t.paused = true
+		t.pausedAt = s.PausedAt
+		t.armPauseLimit()
+		t.emit(EventRestored, "")
+		return
+	}
+
+	remaining := t.remaining()
+	switch t.state {
+	case StateWorking, StateWarning, StateExtended:
+		if remaining <= 0 {
+			// The session ran out while we were down
+			t.closeSessionAt(history.OutcomeCompleted, t.startTime.Add(t.phaseDuration))
+			t.completed++
+			t.resumeFromSleep(-remaining)
+			return
+		}
+		if t.state == StateWorking && remaining <= t.warningTime {
+			t.state = StateWarning
+		}
+	case StateBreak, StateLongBreak:
+		if remaining <= 0 {
+			t.closeSessionAt(history.OutcomeCompleted, t.startTime.Add(t.phaseDuration))
+			t.endBreak(t.state)
+			return
+		}
+	case StateSuspended:
+		// We went down with the machine; the break starts now
+		t.resumeFromSleep(t.clock.Since(t.startTime))
+		return
+	}
+
+	t.emit(EventRestored, "")
+	t.arm(remaining)
 }
@@ -0,0 +1,315 @@
+package timer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+)
+
+// testStart is when the fake clock of test timers starts: a Monday
+var testStart = time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+
+// newTestTimer returns a timer for cfg, or the default config if nil, on
+// a fake clock with recorded actions, along with the fakes driving it
+func newTestTimer(t *testing.T, cfg *config.Config, opts ...Option) (*Timer, *FakeClock, *RecordingActions, *FakeResumeWatcher) {
+	t.Helper()
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	clock := NewFakeClock(testStart)
+	actions := &RecordingActions{}
+	watcher := &FakeResumeWatcher{}
+	opts = append([]Option{WithClock(clock), WithActions(actions), WithResumeWatcher(watcher)}, opts...)
+	return NewTimer(cfg, opts...), clock, actions, watcher
+}
+
+// waitFor fails the test unless cond becomes true within a few seconds,
+// for what goroutines the timer starts do
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// expectState fails the test unless tm is in state
+func expectState(t *testing.T, tm *Timer, state State) {
+	t.Helper()
+	if got := tm.GetState(); got != state {
+		t.Fatalf("state = %s, want %s", got, state)
+	}
+}
+
+func TestStopThenStartDoesNotSuspendEarly(t *testing.T) {
+	tm, clock, actions, _ := newTestTimer(t, nil)
+
+	tm.Start()
+	clock.Advance(30 * time.Minute)
+	tm.Stop()
+	tm.Start()
+
+	// The first session's deadlines, 50 minutes after it began, must not
+	// end the second one
+	clock.Advance(25 * time.Minute)
+	if n := actions.Count("suspend"); n != 0 {
+		t.Fatalf("suspended %d times, want 0", n)
+	}
+	expectState(t, tm, StateWorking)
+	if got, want := tm.GetRemainingTime(), 25*time.Minute; got != want {
+		t.Fatalf("remaining = %s, want %s", got, want)
+	}
+	if n := clock.Pending(); n != 2 {
+		t.Fatalf("%d deadlines pending, want the warning and the end of work", n)
+	}
+}
+
+func TestConcurrentStartStopExtend(t *testing.T) {
+	tm, clock, _, _ := newTestTimer(t, nil)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				switch (i + j) % 4 {
+				case 0:
+					tm.Start()
+				case 1:
+					tm.Extend()
+				case 2:
+					tm.Stop()
+				case 3:
+					tm.Status()
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			clock.Advance(time.Minute)
+		}
+	}()
+	wg.Wait()
+
+	// Whatever the interleaving, the timer is left consistent
+	tm.Stop()
+	expectState(t, tm, StateIdle)
+	if n := clock.Pending(); n != 0 {
+		t.Fatalf("%d deadlines still pending after Stop", n)
+	}
+}
+
+func TestFullCycle(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AlwaysOn = true
+	tm, clock, actions, watcher := newTestTimer(t, cfg)
+
+	tm.Start()
+	expectState(t, tm, StateWorking)
+
+	clock.Advance(45 * time.Minute)
+	expectState(t, tm, StateWarning)
+	if n := actions.Count("notify"); n != 1 {
+		t.Fatalf("%d notifications at the warning, want 1", n)
+	}
+
+	if !tm.Extend() {
+		t.Fatal("Extend refused during the warning")
+	}
+	expectState(t, tm, StateExtended)
+	if tm.Extend() {
+		t.Fatal("Extend allowed twice in one cycle")
+	}
+
+	// The extension replaces what was left of the warning
+	clock.Advance(cfg.ExtendDuration)
+	expectState(t, tm, StateSuspended)
+	waitFor(t, "the suspend", func() bool {
+		return actions.Count("suspend") == 1 && watcher.Waiting() == 1
+	})
+
+	watcher.Resume(0)
+	waitFor(t, "the break", func() bool { return tm.GetState() == StateBreak })
+	if got := tm.GetRemainingTime(); got != cfg.BreakDuration {
+		t.Fatalf("break remaining = %s, want %s", got, cfg.BreakDuration)
+	}
+
+	clock.Advance(cfg.BreakDuration)
+	expectState(t, tm, StateWorking)
+	if tm.ExtendUsed() {
+		t.Fatal("extension still used in the next cycle")
+	}
+	if got := tm.Cycle(); got != 2 {
+		t.Fatalf("cycle = %d, want 2", got)
+	}
+}
+
+func TestReconfigureKeepsWarning(t *testing.T) {
+	tm, clock, actions, _ := newTestTimer(t, nil)
+
+	tm.Start()
+	clock.Advance(30 * time.Minute)
+
+	// A longer warning applies from the next session, not this one
+	cfg := config.DefaultConfig()
+	cfg.WarningTime = 10 * time.Minute
+	cfg.EndAction = config.ActionLock
+	tm.Reconfigure(cfg)
+	tm.Pause()
+	tm.Resume()
+
+	clock.Advance(14 * time.Minute)
+	expectState(t, tm, StateWorking)
+	clock.Advance(time.Minute)
+	expectState(t, tm, StateWarning)
+
+	calls := actions.Calls()
+	if last := calls[len(calls)-1]; last.Name != "notify" || last.Args[1] != "Screen will lock in 5 minutes! Use 'Extend' to delay." {
+		t.Fatalf("warning = %v, want one for the lock in 5 minutes", last)
+	}
+
+	tm.Stop()
+	tm.Start()
+	clock.Advance(40 * time.Minute)
+	expectState(t, tm, StateWarning)
+}
+
+func TestReconfigureDuringWarning(t *testing.T) {
+	tm, clock, _, _ := newTestTimer(t, nil)
+	tm.Start()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			cfg := config.DefaultConfig()
+			cfg.WarningTime = time.Duration(i%10+1) * time.Minute
+			tm.Reconfigure(cfg)
+		}
+	}()
+	clock.Advance(45 * time.Minute)
+	wg.Wait()
+
+	expectState(t, tm, StateWarning)
+}
+
+func TestLongBreaks(t *testing.T) {
+	tests := []struct {
+		every int
+		want  []State
+	}{
+		{2, []State{StateBreak, StateLongBreak, StateBreak, StateLongBreak, StateBreak}},
+		{3, []State{StateBreak, StateBreak, StateLongBreak, StateBreak, StateBreak}},
+		{0, []State{StateBreak, StateBreak, StateBreak, StateBreak, StateBreak}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("every %d", tt.every), func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.AlwaysOn = true
+			cfg.EndAction = config.ActionLock
+			cfg.LongBreakEvery = tt.every
+			tm, clock, _, _ := newTestTimer(t, cfg)
+
+			tm.Start()
+			for i, want := range tt.want {
+				clock.Advance(cfg.WorkDuration)
+				expectState(t, tm, want)
+				d := cfg.BreakDuration
+				if want == StateLongBreak {
+					d = cfg.LongBreakDuration
+				}
+				if got := tm.GetRemainingTime(); got != d {
+					t.Fatalf("break %d lasts %s, want %s", i+1, got, d)
+				}
+				clock.Advance(d)
+				expectState(t, tm, StateWorking)
+			}
+		})
+	}
+}
+
+func TestPauseLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.EndAction = config.ActionLock
+	cfg.AlwaysOn = true
+	cfg.MaxPauseDuration = 10 * time.Minute
+	tm, clock, actions, _ := newTestTimer(t, cfg)
+	limitReached := func() int {
+		n := 0
+		for _, msg := range notifications(actions) {
+			if msg == "Pause limit reached, resuming the timer." {
+				n++
+			}
+		}
+		return n
+	}
+
+	tm.Start()
+	clock.Advance(5 * time.Minute)
+	if !tm.Pause() {
+		t.Fatal("pause refused")
+	}
+	clock.Advance(4 * time.Minute)
+	tm.Resume()
+
+	// The allowance is for all pauses of the cycle together
+	tm.Pause()
+	clock.Advance(5 * time.Minute)
+	if !tm.Paused() {
+		t.Fatal("resumed before the allowance ran out")
+	}
+	clock.Advance(time.Minute)
+	if tm.Paused() || limitReached() != 1 {
+		t.Fatalf("paused %v with %d notifications, want resumed at the limit with one", tm.Paused(), limitReached())
+	}
+	if got := tm.GetRemainingTime(); got != 45*time.Minute {
+		t.Fatalf("%s left, want the 45m left when first paused", got)
+	}
+	if tm.Pause() {
+		t.Fatal("paused with the allowance used up")
+	}
+
+	// Nor does the break bring any back
+	clock.Advance(45 * time.Minute)
+	expectState(t, tm, StateBreak)
+	if tm.Pause() {
+		t.Fatal("paused during the break with the allowance used up")
+	}
+
+	// The next cycle has its own
+	clock.Advance(cfg.BreakDuration)
+	expectState(t, tm, StateWorking)
+	if !tm.Pause() {
+		t.Fatal("pause refused in a new cycle")
+	}
+	clock.Advance(10 * time.Minute)
+	if tm.Paused() || limitReached() != 2 {
+		t.Fatalf("paused %v with %d notifications, want resumed at the limit again", tm.Paused(), limitReached())
+	}
+}
+
+func TestPauseWithoutLimit(t *testing.T) {
+	tm, clock, _, _ := newTestTimer(t, nil)
+
+	tm.Start()
+	tm.Pause()
+	clock.Advance(3 * time.Hour)
+	if !tm.Paused() {
+		t.Fatal("resumed with no pause limit")
+	}
+	if got := tm.GetRemainingTime(); got != 50*time.Minute {
+		t.Fatalf("%s left after the pause, want 50m", got)
+	}
+}
@@ -0,0 +1,124 @@
+package timer
+
+import (
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/wallclock"
+)
+
+// windowAt returns the bounds of the schedule window that now falls in,
+// reading the schedule's wall-clock times in loc. It is a pure function of
+// its arguments.
+//
+// A window belongs to the day it starts on: its weekday and the exception
+// dates are checked against that day, so a Friday 22:00-02:00 window
+// still covers 01:00 on Saturday, and marking Saturday as a day off does
+// not cut it short. A window whose end is not after its start runs past
+// midnight; one that starts and ends at the same time is empty.
+//
+// Bounds are built from calendar dates and clock times in loc rather than
+// by adding durations to midnight, so a window keeps its wall-clock times
+// across daylight saving transitions. A time that doesn't exist because
+// the clocks jump forward is moved forward by the length of the jump
+// (02:30 becomes 03:30), and a time that happens twice when they fall
+// back means its first occurrence.
+//
+// Windows with malformed days or times never match.
+func windowAt(sched config.Schedule, now time.Time, loc *time.Location) (start, end time.Time, ok bool) {
+	now = now.In(loc)
+	year, month, day := now.Date()
+
+	// Yesterday's windows may run into today
+	for _, offset := range []int{-1, 0} {
+		// Noon, since some locations skip midnight when the clocks change
+		date := time.Date(year, month, day+offset, 12, 0, 0, 0, loc)
+		if sched.IsException(date) {
+			continue
+		}
+
+		for _, w := range sched.Windows {
+			if !w.On(date.Weekday()) {
+				continue
+			}
+			start, end, ok := windowOn(w, date)
+			if ok && !now.Before(start) && now.Before(end) {
+				return start, end, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// windowOn returns the bounds of w on date's day, or false if w is
+// malformed or empty
+func windowOn(w config.Window, date time.Time) (start, end time.Time, ok bool) {
+	from, err := config.ParseClock(w.Start)
+	if err != nil {
+		return start, end, false
+	}
+	to, err := config.ParseClock(w.End)
+	if err != nil || from == to {
+		return start, end, false
+	}
+
+	start = atClock(date, from)
+	if to < from {
+		end = atClock(date.AddDate(0, 0, 1), to)
+	} else {
+		end = atClock(date, to)
+	}
+	return start, end, true
+}
+
+// atClock returns the wall-clock time of day clock on date's day, in
+// date's location, resolving daylight saving changes as windowAt
+// describes
+func atClock(date time.Time, clock time.Duration) time.Time {
+	year, month, day := date.Date()
+	return wallclock.Date(year, month, day, int(clock/time.Hour), int(clock%time.Hour/time.Minute), date.Location())
+}
+
+// nextWindow returns the bounds of the first schedule window that starts
+// after now, looking up to a year ahead, or false if there is none
+func nextWindow(sched config.Schedule, now time.Time, loc *time.Location) (start, end time.Time, ok bool) {
+	now = now.In(loc)
+	year, month, day := now.Date()
+
+	for offset := 0; offset <= 366; offset++ {
+		date := time.Date(year, month, day+offset, 12, 0, 0, 0, loc)
+		if sched.IsException(date) {
+			continue
+		}
+
+		// Windows start on the day they belong to, so the first day with
+		// one starting after now has the earliest
+		for _, w := range sched.Windows {
+			if !w.On(date.Weekday()) {
+				continue
+			}
+			s, e, ok := windowOn(w, date)
+			if ok && s.After(now) && (start.IsZero() || s.Before(start)) {
+				start, end = s, e
+			}
+		}
+		if !start.IsZero() {
+			return start, end, true
+		}
+	}
+	return start, end, false
+}
+
+// windowEnd returns when the windows covering a window ending at end
+// finally end, running on through any that adjoin or overlap it
+func windowEnd(sched config.Schedule, end time.Time, loc *time.Location) time.Time {
+	// A week of back-to-back windows is as far as it's worth following
+	for range 14 {
+		_, next, ok := windowAt(sched, end, loc)
+		if !ok {
+			break
+		}
+		end = next
+	}
+	return end
+}
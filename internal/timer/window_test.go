@@ -0,0 +1,180 @@
+package timer
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+)
+
+func TestWindowAt(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	window := func(days, start, end string, exceptions ...string) config.Schedule {
+		d, err := config.ParseDays(days)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config.Schedule{
+			Windows:    []config.Window{{Days: d, Start: start, End: end}},
+			Exceptions: exceptions,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sched      config.Schedule
+		loc        *time.Location
+		now        time.Time // In UTC
+		ok         bool
+		start, end time.Time // In UTC
+	}{
+		{
+			// 2025-03-09 02:00 EST jumps to 03:00 EDT
+			name:  "spring forward moves a skipped start past the jump",
+			sched: window("sun", "02:30", "05:00"),
+			loc:   ny,
+			now:   utc("2025-03-09 08:00"), // 04:00 EDT
+			ok:    true,
+			start: utc("2025-03-09 07:30"), // 03:30 EDT
+			end:   utc("2025-03-09 09:00"), // 05:00 EDT
+		},
+		{
+			name:  "spring forward, before the moved start",
+			sched: window("sun", "02:30", "05:00"),
+			loc:   ny,
+			now:   utc("2025-03-09 07:15"), // 03:15 EDT
+		},
+		{
+			// 2025-11-02 02:00 EDT falls back to 01:00 EST
+			name:  "fall back starts at the first occurrence",
+			sched: window("sun", "01:30", "03:00"),
+			loc:   ny,
+			now:   utc("2025-11-02 05:45"), // The first 01:45, EDT
+			ok:    true,
+			start: utc("2025-11-02 05:30"), // The first 01:30, EDT
+			end:   utc("2025-11-02 08:00"), // 03:00 EST
+		},
+		{
+			name:  "fall back, the second occurrence is still inside",
+			sched: window("sun", "01:30", "03:00"),
+			loc:   ny,
+			now:   utc("2025-11-02 06:15"), // The second 01:15, EST
+			ok:    true,
+			start: utc("2025-11-02 05:30"),
+			end:   utc("2025-11-02 08:00"),
+		},
+		{
+			name:  "fall back, before the first occurrence",
+			sched: window("sun", "01:30", "03:00"),
+			loc:   ny,
+			now:   utc("2025-11-02 05:15"), // The first 01:15, EDT
+		},
+		{
+			name:  "overnight window covers the next morning",
+			sched: window("fri", "22:00", "02:00"),
+			loc:   time.UTC,
+			now:   utc("2025-06-07 01:00"), // Saturday
+			ok:    true,
+			start: utc("2025-06-06 22:00"),
+			end:   utc("2025-06-07 02:00"),
+		},
+		{
+			name:  "overnight window is not cut short by a Saturday off",
+			sched: window("fri", "22:00", "02:00", "2025-06-07"),
+			loc:   time.UTC,
+			now:   utc("2025-06-07 01:00"),
+			ok:    true,
+			start: utc("2025-06-06 22:00"),
+			end:   utc("2025-06-07 02:00"),
+		},
+		{
+			name:  "overnight window is dropped with its Friday",
+			sched: window("fri", "22:00", "02:00", "2025-06-06"),
+			loc:   time.UTC,
+			now:   utc("2025-06-07 01:00"),
+		},
+		{
+			name:  "overnight window ends at its end",
+			sched: window("fri", "22:00", "02:00"),
+			loc:   time.UTC,
+			now:   utc("2025-06-07 02:00"),
+		},
+		{
+			name:  "window starting and ending together is empty",
+			sched: window("mon", "09:00", "09:00"),
+			loc:   time.UTC,
+			now:   utc("2025-06-02 09:00"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := windowAt(tt.sched, tt.now, tt.loc)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Fatalf("window = %s - %s, want %s - %s", start.UTC(), end.UTC(), tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestNextWindow(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The skipped 02:30 resolves as it does in windowAt
+	sched := config.Schedule{Windows: []config.Window{{Days: []string{"sun"}, Start: "02:30", End: "05:00"}}}
+	start, _, ok := nextWindow(sched, time.Date(2025, 3, 8, 12, 0, 0, 0, ny), ny)
+	if want := time.Date(2025, 3, 9, 3, 30, 0, 0, ny); !ok || !start.Equal(want) {
+		t.Fatalf("next start = %s, %v, want %s", start, ok, want)
+	}
+
+	empty := config.Schedule{Windows: []config.Window{{Days: []string{"mon"}, Start: "09:00", End: "09:00"}}}
+	if start, _, ok := nextWindow(empty, testStart, time.UTC); ok {
+		t.Fatalf("empty window starts at %s", start)
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	// Pin local time so that it differs from the configured zone
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	cfg := config.DefaultConfig()
+	cfg.Schedule = config.Schedule{Windows: []config.Window{{Days: []string{"mon"}, Start: "09:00", End: "17:00"}}}
+	now := time.Date(2025, 6, 2, 1, 0, 0, 0, time.UTC) // 10:00 in Tokyo, Sunday 20:00 locally
+
+	loc, err := cfg.Location()
+	if err != nil || loc != time.Local {
+		t.Fatalf("location without a timezone = %v, %v, want local time", loc, err)
+	}
+	if _, _, ok := windowAt(cfg.WeeklySchedule(), now, loc); ok {
+		t.Fatal("in the window in local time")
+	}
+
+	cfg.Timezone = "Asia/Tokyo"
+	loc, err = cfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, _, ok := windowAt(cfg.WeeklySchedule(), now, loc)
+	if want := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC); !ok || !start.Equal(want) {
+		t.Fatalf("window in Tokyo starts %s, %v, want %s", start, ok, want)
+	}
+}
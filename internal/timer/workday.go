@@ -0,0 +1,108 @@
+package timer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+// WindDown stops the timer at the end of a schedule window, following
+// policy (one of config.EndOfDayPolicies): straight away, when the next
+// break would begin, or once the pomodoro under way and its break are
+// over. If the window ends the workday, workday is when that day began,
+// and a summary of the work recorded since is sent once the timer stops;
+// otherwise it is the zero time.
+func (t *Timer) WindDown(policy string, workday time.Time) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	if t.state == StateIdle {
+		if !workday.IsZero() {
+			t.later(func() { t.sendSummary(workday) })
+		}
+		return
+	}
+
+	t.workday = workday
+	switch {
+	case policy == config.EndOfDayNow,
+		policy == config.EndOfDayBreak && (t.state == StateBreak || t.state == StateLongBreak):
+		t.stopForDay()
+	case policy == config.EndOfDayBreak:
+		t.windDown = policy
+	default:
+		t.windDown = config.EndOfDayFinish
+	}
+}
+
+// carryOn calls off a wind-down still waiting for the pomodoro or break
+// to end, as another window has started
+func (t *Timer) carryOn() {
+	t.mu.Lock()
+	defer t.unlock()
+	t.windDown, t.workday = "", time.Time{}
+}
+
+// stopForDay stops the timer as its window has ended, and sums up the
+// workday if that is over too. Must be called with mu held.
+func (t *Timer) stopForDay() {
+	workday := t.workday
+	t.stop(EventWindowEnded)
+
+	// Queued after the session just closed, so it is in the history
+	if !workday.IsZero() {
+		t.later(func() { t.sendSummary(workday) })
+	}
+}
+
+// sendSummary sends the workday over notification with the totals of the
+// work recorded since workday
+func (t *Timer) sendSummary(workday time.Time) {
+	msg := "Workday over"
+	if totals := t.totalsSince(workday); totals != "" {
+		msg += ": " + totals
+	}
+
+	t.logger.Print(msg)
+	if err := t.actions.Notify("Pomoduru", msg); err != nil {
+		t.fail("notification", err)
+	}
+}
+
+// historyReader is implemented by Recorders that can read back what they
+// recorded, such as history.Store
+type historyReader interface {
+	Read(f history.Filter) ([]history.Entry, error)
+}
+
+// totalsSince describes the work recorded since since, or returns "" if
+// the history can't be read
+func (t *Timer) totalsSince(since time.Time) string {
+	r, ok := t.history.(historyReader)
+	if !ok {
+		return ""
+	}
+	entries, err := r.Read(history.Filter{Since: since, Kind: history.KindWork})
+	if err != nil {
+		t.logger.Printf("reading history failed: %v", err)
+		return ""
+	}
+
+	completed := 0
+	var focus time.Duration
+	for _, e := range entries {
+		focus += e.Actual
+		if e.Outcome == history.OutcomeCompleted {
+			completed++
+		}
+	}
+
+	pomodoros := "pomodoros"
+	if completed == 1 {
+		pomodoros = "pomodoro"
+	}
+	focus = focus.Round(time.Minute)
+	return fmt.Sprintf("%d %s completed, %dh%02dm of focus", completed, pomodoros, int(focus.Hours()), int(focus.Minutes())%60)
+}
@@ -0,0 +1,115 @@
+package timer
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/history"
+)
+
+// notifications returns the messages of the notifications sent so far
+func notifications(actions *RecordingActions) []string {
+	var msgs []string
+	for _, call := range actions.Calls() {
+		if call.Name == "notify" {
+			msgs = append(msgs, call.Args[1])
+		}
+	}
+	return msgs
+}
+
+func TestWindDown(t *testing.T) {
+	workday := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		policy string
+		at     time.Duration // Into the work session
+		states []State       // After 0, 30 and 40 more minutes
+		notes  []string
+	}{
+		{
+			name:   "now stops straight away",
+			policy: config.EndOfDayNow,
+			at:     20 * time.Minute,
+			states: []State{StateIdle, StateIdle, StateIdle},
+			notes:  []string{"Workday over: 0 pomodoros completed, 0h20m of focus"},
+		},
+		{
+			name:   "break stops when the pomodoro ends",
+			policy: config.EndOfDayBreak,
+			at:     20 * time.Minute,
+			states: []State{StateWorking, StateIdle, StateIdle},
+			notes: []string{
+				"Break starts in 5 minutes! Use 'Extend' to delay.",
+				"Workday over: 1 pomodoro completed, 0h50m of focus",
+			},
+		},
+		{
+			name:   "break stops a break under way",
+			policy: config.EndOfDayBreak,
+			at:     55 * time.Minute,
+			states: []State{StateIdle, StateIdle, StateIdle},
+			notes: []string{
+				"Break starts in 5 minutes! Use 'Extend' to delay.",
+				"Time's up! Taking a break...",
+				"Workday over: 1 pomodoro completed, 0h50m of focus",
+			},
+		},
+		{
+			name:   "finish lets the pomodoro and its break run out",
+			policy: config.EndOfDayFinish,
+			at:     20 * time.Minute,
+			states: []State{StateWorking, StateBreak, StateIdle},
+			notes: []string{
+				"Break starts in 5 minutes! Use 'Extend' to delay.",
+				"Time's up! Taking a break...",
+				"Workday over: 1 pomodoro completed, 0h50m of focus",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.EndAction = config.ActionOverlay
+			cfg.AlwaysOn = true
+			store := history.Open(filepath.Join(t.TempDir(), "history.jsonl"))
+			tm, clock, actions, _ := newTestTimer(t, cfg, WithHistory(store))
+
+			tm.Start()
+			clock.Advance(tt.at)
+			tm.WindDown(tt.policy, workday)
+
+			for i, d := range []time.Duration{0, 30 * time.Minute, 10 * time.Minute} {
+				clock.Advance(d)
+				expectState(t, tm, tt.states[i])
+			}
+			if got := notifications(actions); !slices.Equal(got, tt.notes) {
+				t.Fatalf("notifications = %q\nwant %q", got, tt.notes)
+			}
+		})
+	}
+}
+
+func TestWindDownWithoutSummary(t *testing.T) {
+	tm, clock, actions, _ := newTestTimer(t, nil)
+
+	// Another window opens later the same day, so the workday isn't over
+	tm.Start()
+	clock.Advance(20 * time.Minute)
+	tm.WindDown(config.EndOfDayNow, time.Time{})
+
+	expectState(t, tm, StateIdle)
+	if got := notifications(actions); len(got) != 0 {
+		t.Fatalf("notifications = %q, want none", got)
+	}
+
+	// Idle already, only the summary is left to send
+	tm.WindDown(config.EndOfDayFinish, testStart)
+	if got := notifications(actions); len(got) != 1 {
+		t.Fatalf("notifications = %q, want the summary", got)
+	}
+}
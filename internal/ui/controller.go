@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aniketvish/pomoduru/internal/control"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// Controller is what the UI drives: a timer owned by this process, or a
+// daemon's timer reached through its control socket
+type Controller interface {
+	Start() error
+	Stop() error
+	Extend() error
+	Pause() error
+	Resume() error
+	Status() (timer.Status, error)
+
+	// Subscribe streams the timer's events until cancel is called. The
+	// channel is closed if the timer goes away.
+	Subscribe() (events <-chan timer.Event, cancel func(), err error)
+}
+
+// Local returns a Controller for a timer owned by this process
+func Local(t *timer.Timer) Controller {
+	return localController{t}
+}
+
+type localController struct {
+	t *timer.Timer
+}
+
+func (c localController) Start() error {
+	c.t.Start()
+	return nil
+}
+
+func (c localController) Stop() error {
+	c.t.Stop()
+	return nil
+}
+
+func (c localController) Extend() error {
+	return refused("extend", c.t.Extend())
+}
+
+func (c localController) Pause() error {
+	return refused("pause", c.t.Pause())
+}
+
+func (c localController) Resume() error {
+	return refused("resume", c.t.Resume())
+}
+
+func (c localController) Status() (timer.Status, error) {
+	return c.t.Status(), nil
+}
+
+func (c localController) Subscribe() (<-chan timer.Event, func(), error) {
+	events, cancel := c.t.Subscribe()
+	return events, cancel, nil
+}
+
+func refused(what string, ok bool) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("cannot %s right now", what)
+}
+
+// Remote returns a Controller for a daemon reached through c
+func Remote(c *control.Client) Controller {
+	return remoteController{c}
+}
+
+type remoteController struct {
+	c *control.Client
+}
+
+func (r remoteController) Start() error {
+	_, err := r.c.Start(0)
+	return err
+}
+
+func (r remoteController) Stop() error {
+	_, err := r.c.Stop()
+	return err
+}
+
+func (r remoteController) Extend() error {
+	_, err := r.c.Extend()
+	return err
+}
+
+func (r remoteController) Pause() error {
+	_, err := r.c.Pause()
+	return err
+}
+
+func (r remoteController) Resume() error {
+	_, err := r.c.Resume()
+	return err
+}
+
+func (r remoteController) Status() (timer.Status, error) {
+	st, err := r.c.Status()
+	if err != nil {
+		return st, errLostDaemon
+	}
+	return st, nil
+}
+
+func (r remoteController) Subscribe() (<-chan timer.Event, func(), error) {
+	_, events, stop, err := r.c.Subscribe()
+	return events, stop, err
+}
+
+var errLostDaemon = errors.New("lost connection to the pomoduru daemon")
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -44,6 +45,12 @@ var (
 		Background(lipgloss.Color("#00B4D8")).
 		Padding(0, 2)
 
+	longBreakStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7209B7")).
+		Padding(0, 2)
+
 	extendedStyle = lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#FAFAFA")).
@@ -70,24 +77,39 @@ var (
 	infoStyle = lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#8B8BAE")).
 		Padding(1, 0)
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B35"))
+
+	noticeStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#4CC9F0"))
 )
 
 // Model represents the UI model
 type Model struct {
-	timer       *timer.Timer
+	ctl         Controller
 	config      *config.Config
 	progress    progress.Model
 	spinner     spinner.Model
 	state       timer.State
+	paused      bool
+	extendUsed  bool
+	err         error // Last command refused or failed
+	statusErr   error // Failed system action, or lost daemon
+	notice      string // What the last config reload changed, until a key is pressed
+	cycle       int
 	remaining   time.Duration
+	total       time.Duration
+	nextStart   time.Time // The scheduler's plan
+	nextStop    time.Time
 	width       int
 	height      int
 	showHelp    bool
 	lastTick    time.Time
 }
 
-// NewModel creates a new UI model
-func NewModel(cfg *config.Config, t *timer.Timer) Model {
+// NewModel creates a new UI model driving ctl
+func NewModel(cfg *config.Config, ctl Controller) Model {
 	p := progress.New(
 		progress.WithDefaultGradient(),
 		progress.WithWidth(40),
@@ -97,8 +119,8 @@ func NewModel(cfg *config.Config, t *timer.Timer) Model {
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#F72585"))
 
-	return Model{
-		timer:     t,
+	m := Model{
+		ctl:       ctl,
 		config:    cfg,
 		progress:  p,
 		spinner:   s,
@@ -108,13 +130,13 @@ func NewModel(cfg *config.Config, t *timer.Timer) Model {
 		showHelp:  false,
 		lastTick:  time.Now(),
 	}
+	
+	// Later changes arrive as events
+	return m.refresh()
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	// Set up timer callback
-	m.timer.SetStateChangeCallback(m.handleStateChange)
-	
 	// Start ticking for UI updates
 	return tea.Batch(
 		m.spinner.Tick,
@@ -131,46 +153,64 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.progress.Width = min(msg.Width-padding*2-4, 60)
 		
 	case tea.KeyMsg:
+		m.notice = ""
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "s", " ":
 			if m.state == timer.StateIdle {
-				m.timer.Start()
+				m.err = m.ctl.Start()
+			} else {
+				m.err = m.ctl.Stop()
+			}
+			m = m.refresh()
+		case "p":
+			if m.paused {
+				m.err = m.ctl.Resume()
 			} else {
-				m.timer.Stop()
+				m.err = m.ctl.Pause()
 			}
+			m = m.refresh()
 		case "e":
 			if m.state == timer.StateWarning {
-				if m.timer.Extend() {
-					// Extension successful
-				}
+				m.err = m.ctl.Extend()
+				m = m.refresh()
 			}
 		case "h", "?":
 			m.showHelp = !m.showHelp
 		}
 		
 	case tickMsg:
-		// Update every second
+		// Count down locally between events
 		now := time.Now()
-		if now.Sub(m.lastTick) >= time.Second {
-			m.lastTick = now
-			m.remaining = m.timer.GetRemainingTime()
-			m.state = m.timer.GetState()
+		if m.isActive() && !m.paused {
+			m.remaining -= now.Sub(m.lastTick)
 		}
+		m.lastTick = now
 		return m, tickCmd()
 		
-	case stateChangeMsg:
-		m.state = timer.State(msg.state)
-		m.remaining = msg.remaining
-		
-		// Reset progress when starting new timer
-		if m.state == timer.StateWorking {
+	case eventMsg:
+		m = m.apply(msg.Status)
+		switch msg.Kind {
+		case timer.EventStarted:
+			// Reset progress when starting new timer
 			m.progress = progress.New(
 				progress.WithDefaultGradient(),
 				progress.WithWidth(min(m.width-padding*2-4, 60)),
 			)
+		case timer.EventError:
+			m.statusErr = errors.New(msg.Message)
+		case timer.EventReconfigured:
+			m.notice = msg.Message
+			// The timer may be a daemon's, so read the settings it switched to
+			return m, loadConfig
 		}
+		
+	case configMsg:
+		m.config = msg.config
+		
+	case disconnectedMsg:
+		m.statusErr = errLostDaemon
 	}
 	
 	var cmd tea.Cmd
@@ -180,6 +220,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View renders the UI
 func (m Model) View() string {
+	if m.config.EndAction == config.ActionOverlay && (m.state == timer.StateBreak || m.state == timer.StateLongBreak) {
+		return m.renderOverlay()
+	}
+	
 	var b strings.Builder
 	
 	// Title
@@ -202,14 +246,30 @@ func (m Model) View() string {
 		displayStr = extendedStyle.Render("⏰ " + stateStr + " - " + timeStr)
 	case timer.StateBreak:
 		displayStr = breakStyle.Render("☕ " + stateStr + " - " + timeStr)
+	case timer.StateLongBreak:
+		displayStr = longBreakStyle.Render("🌴 " + stateStr + " - " + timeStr)
 	case timer.StateSuspended:
 		displayStr = statusStyle.Render("💤 System suspended - Taking break")
 	}
 	
+	if m.paused {
+		displayStr = statusStyle.Render("⏸️  Paused - " + stateStr + " - " + timeStr)
+	}
+	
 	b.WriteString(displayStr + "\n\n")
 	
+	// Surface failed commands, notifications or suspends
+	for _, err := range []error{m.err, m.statusErr} {
+		if err != nil {
+			b.WriteString(errorStyle.Render("❗ "+err.Error()) + "\n\n")
+		}
+	}
+	if m.notice != "" {
+		b.WriteString(noticeStyle.Render("⚙️  "+m.notice) + "\n\n")
+	}
+	
 	// Progress bar (only for active timers)
-	if m.state == timer.StateWorking || m.state == timer.StateWarning || m.state == timer.StateExtended || m.state == timer.StateBreak {
+	if m.isActive() {
 		progress := m.calculateProgress()
 		progressBar := m.progress.ViewAs(progress)
 		b.WriteString(progressBar + "\n\n")
@@ -219,12 +279,22 @@ func (m Model) View() string {
 	b.WriteString(m.renderControls() + "\n\n")
 	
 	// Info
+	if m.config.LongBreakEvery > 0 && m.state != timer.StateIdle {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("🍅 Cycle %d/%d\n", m.cycle, m.config.LongBreakEvery)))
+	}
+	
 	if m.config.AlwaysOn {
 		b.WriteString(infoStyle.Render("🔄 Always-on mode: Timer will restart automatically after breaks\n"))
 	}
 	
-	if m.config.ScheduleEnabled {
-		b.WriteString(infoStyle.Render(fmt.Sprintf("📅 Scheduled: %s - %s\n", m.config.ScheduleStart, m.config.ScheduleEnd)))
+	if m.config.ScheduleEnabled && len(m.config.ScheduleCron) > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("📅 Scheduled starts: %s\n", strings.Join(m.config.ScheduleCron, "; "))))
+	} else if m.config.ScheduleEnabled {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("📅 Scheduled today: %s\n", m.todaysWindows())))
+	}
+	
+	if plan := m.formatPlan(); plan != "" {
+		b.WriteString(infoStyle.Render(plan + "\n"))
 	}
 	
 	// Help
@@ -252,11 +322,95 @@ func (m Model) formatState() string {
 		return "Extended"
 	case timer.StateBreak:
 		return "Break Time"
+	case timer.StateLongBreak:
+		return "Long Break"
 	default:
 		return "Idle"
 	}
 }
 
+// refresh updates the model from the controller's current status
+func (m Model) refresh() Model {
+	st, err := m.ctl.Status()
+	if err != nil {
+		m.statusErr = err
+		return m
+	}
+	
+	return m.apply(st)
+}
+
+// apply updates the model from a status just received
+func (m Model) apply(st timer.Status) Model {
+	m.statusErr = nil
+	if st.Error != "" {
+		m.statusErr = errors.New(st.Error)
+	}
+	m.lastTick = time.Now()
+	m.state = st.State
+	m.remaining = st.Remaining
+	m.total = st.Total
+	m.paused = st.Paused
+	m.extendUsed = st.ExtendUsed
+	m.cycle = st.Cycle
+	m.nextStart = st.NextStart
+	m.nextStop = st.NextStop
+	return m
+}
+
+// todaysWindows lists the scheduled windows for today
+func (m Model) todaysWindows() string {
+	loc, _ := m.config.Location()
+	now := time.Now().In(loc)
+	sched := m.config.WeeklySchedule()
+	if sched.IsException(now) {
+		return "day off"
+	}
+	
+	var windows []string
+	for _, w := range sched.Windows {
+		if w.On(now.Weekday()) {
+			windows = append(windows, w.Start+" - "+w.End)
+		}
+	}
+	if len(windows) == 0 {
+		return "nothing"
+	}
+	return strings.Join(windows, ", ")
+}
+
+// formatPlan describes what the scheduler does next: stop the work under
+// way, or start the next session
+func (m Model) formatPlan() string {
+	switch {
+	case !m.nextStop.IsZero() && m.state != timer.StateIdle:
+		return "⏹️  Schedule ends at " + m.formatWhen(m.nextStop)
+	case !m.nextStart.IsZero():
+		return "⏭️  Next session starts at " + m.formatWhen(m.nextStart)
+	}
+	return ""
+}
+
+// formatWhen formats a time in the schedule's time zone, with the day if
+// it isn't today
+func (m Model) formatWhen(t time.Time) string {
+	loc, _ := m.config.Location()
+	t = t.In(loc)
+	if t.Format(config.DateLayout) != time.Now().In(loc).Format(config.DateLayout) {
+		return t.Format("Mon 15:04")
+	}
+	return t.Format("15:04")
+}
+
+// isActive reports whether a work or break countdown is running
+func (m Model) isActive() bool {
+	switch m.state {
+	case timer.StateWorking, timer.StateWarning, timer.StateExtended, timer.StateBreak, timer.StateLongBreak:
+		return true
+	}
+	return false
+}
+
 func (m Model) formatTime() string {
 	if m.remaining <= 0 {
 		return "00:00"
@@ -268,18 +422,7 @@ func (m Model) formatTime() string {
 }
 
 func (m Model) calculateProgress() float64 {
-	var total time.Duration
-	
-	switch m.state {
-	case timer.StateWorking, timer.StateWarning:
-		total = m.config.WorkDuration
-	case timer.StateExtended:
-		total = m.config.ExtendDuration
-	case timer.StateBreak:
-		total = m.config.BreakDuration
-	default:
-		return 0
-	}
+	total := m.total
 	
 	if total == 0 {
 		return 0
@@ -307,7 +450,15 @@ func (m Model) renderControls() string {
 		controls = append(controls, buttonStyle.Render("[S] Stop"))
 	}
 	
-	if m.state == timer.StateWarning && !m.timer.ExtendUsed() {
+	if m.isActive() {
+		if m.paused {
+			controls = append(controls, activeButtonStyle.Render("[P] Resume"))
+		} else {
+			controls = append(controls, buttonStyle.Render("[P] Pause"))
+		}
+	}
+	
+	if m.state == timer.StateWarning && !m.paused && !m.extendUsed {
 		controls = append(controls, activeButtonStyle.Render("[E] Extend (+5min)"))
 	}
 	
@@ -316,11 +467,27 @@ func (m Model) renderControls() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, controls...)
 }
 
+// renderOverlay covers the whole terminal during a break when the end
+// action is the break overlay
+func (m Model) renderOverlay() string {
+	msg := lipgloss.JoinVertical(lipgloss.Center,
+		breakStyle.Render("☕ "+m.formatState()+" - "+m.formatTime()),
+		"",
+		"Step away from the screen.",
+		"",
+		infoStyle.Render("[P] Pause  [Q] Quit"),
+	)
+	
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, msg,
+		lipgloss.WithWhitespaceBackground(lipgloss.Color("#1B1B2F")))
+}
+
 func (m Model) renderHelp() string {
 	help := []string{
 		"Controls:",
 		"  [S] or [Space]  - Start/Stop timer",
 		"  [E]             - Extend work session (5min, once per cycle)",
+		"  [P]             - Pause/Resume the current session",
 		"  [H] or [?]      - Toggle help",
 		"  [Q] or [Ctrl+C] - Quit",
 		"",
@@ -328,7 +495,9 @@ func (m Model) renderHelp() string {
 		"  • Automatic system suspend after work",
 		"  • 5-minute warning before suspend",
 		"  • One-time 5-minute extension",
+		"  • Pause and resume without losing the cycle",
 		"  • Configurable work/break durations",
+		"  • Long break after every few cycles",
 		"  • Always-on mode",
 		"  • Scheduled start times",
 	}
@@ -346,13 +515,33 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-type stateChangeMsg struct {
-	state     timer.State
-	remaining time.Duration
+// eventMsg carries a timer event into the program
+type eventMsg timer.Event
+
+// disconnectedMsg reports that the event stream has ended
+type disconnectedMsg struct{}
+
+// configMsg carries settings read from disk after a reload
+type configMsg struct {
+	config *config.Config
+}
+
+// loadConfig reads the settings, away from Update as it touches the disk
+func loadConfig() tea.Msg {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		// The reload's event already told of the problem
+		return nil
+	}
+	return configMsg{cfg}
 }
 
-func (m Model) handleStateChange(state timer.State, remaining time.Duration) {
-	// This will be called from the timer, but since bubbletea is single-threaded,
-	// we'll handle state changes through the tick messages
+// Forward sends every event from events into p as it arrives, so
+// transitions render at once. It returns when events is closed.
+func Forward(p *tea.Program, events <-chan timer.Event) {
+	for ev := range events {
+		p.Send(eventMsg(ev))
+	}
+	p.Send(disconnectedMsg{})
 }
 
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aniketvish/pomoduru/internal/config"
+	"github.com/aniketvish/pomoduru/internal/timer"
+)
+
+// idleController is a Controller for a timer that is never started
+type idleController struct{}
+
+func (idleController) Start() error  { return nil }
+func (idleController) Stop() error   { return nil }
+func (idleController) Extend() error { return nil }
+func (idleController) Pause() error  { return nil }
+func (idleController) Resume() error { return nil }
+
+func (idleController) Status() (timer.Status, error) {
+	return timer.Status{State: timer.StateIdle}, nil
+}
+
+func (idleController) Subscribe() (<-chan timer.Event, func(), error) {
+	return make(chan timer.Event), func() {}, nil
+}
+
+func TestReconfiguredLoadsConfigInCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saved := config.DefaultConfig()
+	saved.WorkDuration = 25 * time.Minute
+	if err := os.MkdirAll(filepath.Dir(config.ConfigPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(config.DefaultConfig(), idleController{})
+	ev := timer.Event{Kind: timer.EventReconfigured, Message: "Settings reloaded: work_duration 50m → 25m"}
+	next, cmd := m.Update(eventMsg(ev))
+	m = next.(Model)
+	if m.notice != ev.Message {
+		t.Fatalf("notice = %q, want the event's message", m.notice)
+	}
+	// Update itself leaves the disk alone
+	if m.config.WorkDuration != 50*time.Minute || cmd == nil {
+		t.Fatalf("config work %s and command %v after the event, want 50m and a load", m.config.WorkDuration, cmd)
+	}
+
+	next, _ = m.Update(cmd())
+	if got := next.(Model).config.WorkDuration; got != 25*time.Minute {
+		t.Fatalf("loaded work %s, want 25m", got)
+	}
+}
+
+func TestReconfiguredKeepsConfigOnError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(config.ConfigPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.ConfigPath(), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	m := NewModel(cfg, idleController{})
+	_, cmd := m.Update(eventMsg(timer.Event{Kind: timer.EventReconfigured, Message: "Settings not reloaded"}))
+	if msg := cmd(); msg != nil {
+		t.Fatalf("unreadable config loaded as %v, want no message", msg)
+	}
+}
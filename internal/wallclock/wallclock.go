@@ -0,0 +1,35 @@
+// Package wallclock turns calendar dates and clock times into instants,
+// resolving daylight saving changes the same way in every location.
+package wallclock
+
+import "time"
+
+// Date is time.Date to the minute, resolving daylight saving changes the
+// same way in every location: a time skipped when the clocks go forward
+// moves forward by the length of the jump (02:30 becomes 03:30), and one
+// repeated when they fall back means its first occurrence. time.Date
+// alone resolves them differently from one location to another.
+func Date(year int, month time.Month, day, hour, min int, loc *time.Location) time.Time {
+	want := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	t := time.Date(year, month, day, hour, min, 0, 0, loc)
+
+	// The offsets in effect either side of any nearby transition. The
+	// earlier one gives the first of two repeated times, and lands past
+	// the jump for a skipped one.
+	_, before := t.Add(-12 * time.Hour).Zone()
+	_, after := t.Add(12 * time.Hour).Zone()
+	early := want.Add(-time.Duration(before) * time.Second).In(loc)
+	late := want.Add(-time.Duration(after) * time.Second).In(loc)
+
+	if !Reading(early).Equal(want) && Reading(late).Equal(want) {
+		return late
+	}
+	return early
+}
+
+// Reading returns the wall-clock reading of t, to the minute, as a time
+// in UTC, so that readings can be compared across daylight saving changes
+func Reading(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
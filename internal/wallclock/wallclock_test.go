@@ -0,0 +1,38 @@
+package wallclock
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		zone  string
+		year  int
+		month time.Month
+		day   int
+		hour  int
+		min   int
+		want  string // RFC 3339
+	}{
+		{"America/New_York", 2025, 3, 9, 2, 30, "2025-03-09T03:30:00-04:00"},  // Skipped
+		{"America/New_York", 2025, 11, 2, 1, 30, "2025-11-02T01:30:00-04:00"}, // Repeated
+		{"America/New_York", 2025, 6, 2, 9, 0, "2025-06-02T09:00:00-04:00"},
+		{"Europe/Berlin", 2025, 3, 30, 2, 0, "2025-03-30T03:00:00+02:00"},
+		{"Europe/Berlin", 2025, 10, 26, 2, 15, "2025-10-26T02:15:00+02:00"},
+		{"Australia/Lord_Howe", 2025, 10, 5, 2, 15, "2025-10-05T02:45:00+11:00"}, // A half-hour jump
+		{"UTC", 2025, 12, 32, 24, 0, "2026-01-02T00:00:00Z"},                     // Normalised
+	}
+
+	for _, tt := range tests {
+		loc, err := time.LoadLocation(tt.zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := Date(tt.year, tt.month, tt.day, tt.hour, tt.min, loc)
+		if got.Format(time.RFC3339) != tt.want {
+			t.Errorf("Date(%d-%02d-%02d %02d:%02d %s) = %s, want %s", tt.year, tt.month, tt.day, tt.hour, tt.min, tt.zone, got.Format(time.RFC3339), tt.want)
+		}
+	}
+}